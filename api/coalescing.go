@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/providers"
+	"github.com/LasramR/sclng-backend-test-lasramR/util"
+)
+
+// Maximum time a replica waits for another replica to cache the result of an identical request
+const distributedCoalescingWait = 10 * time.Second
+
+// Time a replica holds the lock of a request, the fetch is cancelled past it so that another replica never fetches concurrently.
+// It exceeds the 30 seconds timeout of the services along with the 30 seconds given to enrich repositories
+const distributedCoalescingLockTTL = 90 * time.Second
+
+// Interval at which the cache is checked while waiting for another replica
+const distributedCoalescingPollInterval = 100 * time.Millisecond
+
+// Deduplicates concurrent identical requests: in process with a Coalescer, and accross replicas when a LockProvider is set.
+// fetch is run at most once per key and its successful result is cached in a CacheEnvelope before being shared with every waiter
+func coalescedFetch[T any](
+	ctx context.Context,
+	coalescer *util.Coalescer[providers.CacheEnvelope[T]],
+	lockProvider providers.LockProvider,
+	cacheProvider providers.CacheProvider,
+	freshFor, staleFor time.Duration,
+	key string,
+	fetch func(ctx context.Context) (T, error),
+) (providers.CacheEnvelope[T], error) {
+	envelope, err, _ := coalescer.Do(ctx, key, func() (providers.CacheEnvelope[T], error) {
+		// The shared fetch must not be cancelled when the first caller goes away, it must end before its lock expires though
+		fetchCtx, cancelFetch := context.WithTimeout(context.WithoutCancel(ctx), distributedCoalescingLockTTL)
+		defer cancelFetch()
+
+		if lockProvider != nil {
+			unlock, acquired, err := lockProvider.TryLock(fetchCtx, key, distributedCoalescingLockTTL)
+
+			if err == nil && acquired {
+				defer unlock()
+			} else if err == nil {
+				// Another replica is fetching the same key, waits for its result to be cached and fetches it ourselves otherwise
+				if envelope, ok := waitForCache[T](ctx, cacheProvider, key, distributedCoalescingWait); ok {
+					return envelope, nil
+				}
+			}
+		}
+
+		value, err := fetch(fetchCtx)
+
+		if err != nil {
+			return providers.CacheEnvelope[T]{}, err
+		}
+
+		// Set in cache before releasing the lock so that waiting replicas find it
+		envelope, _ := providers.SetEnveloped(fetchCtx, cacheProvider, key, value, freshFor, staleFor)
+
+		return envelope, nil
+	})
+
+	return envelope, err
+}
+
+// Polls the cache until key is set with a fresh value or wait is elapsed
+func waitForCache[T any](ctx context.Context, cacheProvider providers.CacheProvider, key string, wait time.Duration) (providers.CacheEnvelope[T], bool) {
+	var envelope providers.CacheEnvelope[T]
+	ticker := time.NewTicker(distributedCoalescingPollInterval)
+	defer ticker.Stop()
+	timeout := time.After(wait)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return envelope, false
+		case <-timeout:
+			return envelope, false
+		case <-ticker.C:
+			// A stale value is the one being refreshed, it must not be mistaken for the result of another replica
+			if envelope, err := providers.GetEnveloped[T](ctx, cacheProvider, key); err == nil && envelope.IsFresh(time.Now()) {
+				return envelope, true
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/providers"
+	"github.com/LasramR/sclng-backend-test-lasramR/util"
+)
+
+type MockLockProvider struct {
+	acquired bool
+	// Set to the expiration of the acquired lock when not nil
+	lockedFor *time.Duration
+}
+
+func (mlp MockLockProvider) TryLock(ctx context.Context, key string, expiresIn time.Duration) (func(), bool, error) {
+	if mlp.lockedFor != nil {
+		*mlp.lockedFor = expiresIn
+	}
+
+	return func() {}, mlp.acquired, nil
+}
+
+func TestCoalescedFetch_ConcurrentRequests(t *testing.T) {
+	coalescer := util.NewCoalescer[providers.CacheEnvelope[int]]()
+	cacheProvider := providers.NewMemoryCacheProvider(10, 1024)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if envelope, err := coalescedFetch(context.Background(), coalescer, nil, cacheProvider, time.Minute, time.Minute, "key", fetch); envelope.Value != 42 || err != nil {
+				t.Errorf("Every request should have received the fetched value")
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("Concurrent identical requests should have been fetched once, fetched %d times", calls.Load())
+	}
+
+	if cached, err := providers.GetEnveloped[int](context.Background(), cacheProvider, "key"); err != nil || cached.Value != 42 {
+		t.Fatalf("Fetched value should have been cached")
+	}
+}
+
+func TestCoalescedFetch_WaitsForAnotherReplica(t *testing.T) {
+	coalescer := util.NewCoalescer[providers.CacheEnvelope[int]]()
+	cacheProvider := providers.NewMemoryCacheProvider(10, 1024)
+
+	// Another replica holds the lock and caches its result shortly after
+	go func() {
+		time.Sleep(150 * time.Millisecond)
+		_, _ = providers.SetEnveloped(context.Background(), cacheProvider, "key", 42, time.Minute, time.Minute)
+	}()
+
+	envelope, err := coalescedFetch(context.Background(), coalescer, MockLockProvider{acquired: false}, cacheProvider, time.Minute, time.Minute, "key", func(ctx context.Context) (int, error) {
+		return 0, errors.New("should not fetch")
+	})
+
+	if err != nil || envelope.Value != 42 {
+		t.Fatalf("Request should have used the value cached by the replica holding the lock")
+	}
+}
+
+func TestCoalescedFetch_EndsBeforeLockExpires(t *testing.T) {
+	coalescer := util.NewCoalescer[providers.CacheEnvelope[int]]()
+	cacheProvider := providers.NewMemoryCacheProvider(10, 1024)
+	var lockedFor time.Duration
+
+	_, err := coalescedFetch(context.Background(), coalescer, MockLockProvider{acquired: true, lockedFor: &lockedFor}, cacheProvider, time.Minute, time.Minute, "key", func(ctx context.Context) (int, error) {
+		deadline, ok := ctx.Deadline()
+
+		if !ok || time.Until(deadline) > lockedFor {
+			return 0, errors.New("fetch may outlive its lock")
+		}
+
+		return 42, nil
+	})
+
+	if err != nil {
+		t.Fatalf("Fetch should have been bounded by the expiration of its lock, got %s", err)
+	}
+
+	if lockedFor < 60*time.Second {
+		t.Fatalf("Lock should have outlived the services timeouts, expires in %s", lockedFor)
+	}
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/model"
+	"github.com/LasramR/sclng-backend-test-lasramR/util"
+)
+
+// Representation of a response body
+type responseFormat string
+
+const (
+	jsonFormat   responseFormat = "json"
+	csvFormat    responseFormat = "csv"
+	ndjsonFormat responseFormat = "ndjson"
+)
+
+// Content-Type of each response format
+var responseFormatContentTypes = map[responseFormat]string{
+	jsonFormat:   "application/json",
+	csvFormat:    "text/csv; charset=utf-8",
+	ndjsonFormat: "application/x-ndjson",
+}
+
+// Media types of the Accept header and the response format they negotiate
+var acceptedMediaTypes = map[string]responseFormat{
+	"*/*":                  jsonFormat,
+	"application/*":        jsonFormat,
+	"application/json":     jsonFormat,
+	"text/*":               csvFormat,
+	"text/csv":             csvFormat,
+	"application/x-ndjson": ndjsonFormat,
+	"application/ndjson":   ndjsonFormat,
+}
+
+// Prefix of the CSV columns holding the bytes of a language, eg lang:Go
+const csvLanguageColumnPrefix = "lang:"
+
+// CSV cells of the fields that are not marshalled as scalars : licenses are represented by their SPDX id and topics are separated by ;
+// Contributors are represented by their logins separated by ;, activity by its total commits and releases by the latest version, pending and failed enrichments are empty cells
+var csvFieldCells = map[string]func(repo *model.Repository) string{
+	"license": func(repo *model.Repository) string {
+		if repo.License.IsNull {
+			return ""
+		}
+		return repo.License.Value.SpdxId
+	},
+	"topics": func(repo *model.Repository) string {
+		return strings.Join(repo.Topics, ";")
+	},
+	"contributors": func(repo *model.Repository) string {
+		if repo.Contributors == nil || repo.Contributors.IsNull || repo.Contributors.Value.Pending {
+			return ""
+		}
+		logins := make([]string, 0, len(repo.Contributors.Value.Top))
+		for _, contributor := range repo.Contributors.Value.Top {
+			logins = append(logins, contributor.Login)
+		}
+		return strings.Join(logins, ";")
+	},
+	"activity": func(repo *model.Repository) string {
+		if repo.Activity == nil || repo.Activity.IsNull || repo.Activity.Value.Pending {
+			return ""
+		}
+		return strconv.Itoa(repo.Activity.Value.TotalCommits)
+	},
+	"releases": func(repo *model.Repository) string {
+		if repo.Releases == nil || repo.Releases.IsNull || repo.Releases.Value.Latest.IsNull {
+			return ""
+		}
+		return repo.Releases.Value.Latest.Value.Version
+	},
+}
+
+// Negotiates the response format from the format query parameter, or the Accept header if unset.
+// Accept headers without supported media types fall back to JSON, error != nil if the format query parameter is not supported
+func negotiateFormat(r *http.Request) (responseFormat, error) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		if _, ok := responseFormatContentTypes[responseFormat(format)]; !ok {
+			return jsonFormat, fmt.Errorf("%s format is not supported [%s,%s,%s] allowed", format, csvFormat, jsonFormat, ndjsonFormat)
+		}
+
+		return responseFormat(format), nil
+	}
+
+	type acceptedFormat struct {
+		format  responseFormat
+		quality float64
+	}
+	accepted := make([]acceptedFormat, 0)
+
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		format, ok := acceptedMediaTypes[mediaType]
+		if !ok {
+			continue
+		}
+
+		quality := 1.0
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil {
+			quality = q
+		}
+
+		if quality > 0 {
+			accepted = append(accepted, acceptedFormat{format, quality})
+		}
+	}
+
+	// Media ranges of equal quality are preferred in the order they were given
+	slices.SortStableFunc(accepted, func(a, b acceptedFormat) int {
+		return cmp.Compare(b.quality, a.quality)
+	})
+
+	if len(accepted) == 0 {
+		return jsonFormat, nil
+	}
+
+	return accepted[0].format, nil
+}
+
+// Marshals the error object in the given format, CSV errors hold one row per reason
+func marshalError(format responseFormat, apiError model.ApiError) ([]byte, error) {
+	var body bytes.Buffer
+
+	if format != csvFormat {
+		err := json.NewEncoder(&body).Encode(apiError)
+		return body.Bytes(), err
+	}
+
+	writer := csv.NewWriter(&body)
+	_ = writer.Write([]string{"status", "reason"})
+	for _, reason := range apiError.Reason {
+		_ = writer.Write([]string{strconv.Itoa(apiError.Status), reason})
+	}
+	writer.Flush()
+
+	return body.Bytes(), writer.Error()
+}
+
+// Marshals one repository restricted to the fields of selection per line, failed aggregations are skipped
+func marshalRepositoriesNdjson(repos []*model.Repository, selection []string) ([]byte, error) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+
+	selected, err := selectRepositoriesFields(repos, selection)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, repo := range selected {
+		if repo == nil {
+			continue
+		}
+
+		if err := encoder.Encode(repo); err != nil {
+			return nil, err
+		}
+	}
+
+	return body.Bytes(), nil
+}
+
+// Marshals one row per repository with a column per field of selection, failed aggregations are skipped.
+// Languages are flattened into a lang:<language> column per language of the repositories, holding its bytes
+func marshalRepositoriesCsv(repos []*model.Repository, selection []string) ([]byte, error) {
+	fields := slices.DeleteFunc(slices.Clone(selection), func(field string) bool {
+		return field == "languages"
+	})
+	withLanguages := len(fields) != len(selection)
+
+	languages := make(map[string]bool)
+	if withLanguages {
+		for _, repo := range repos {
+			if repo != nil {
+				for _, language := range repo.Languages {
+					languages[language.Name] = true
+				}
+			}
+		}
+	}
+	// Language columns are sorted so that the column scheme is stable for a given set of languages
+	sortedLanguages := util.SortedKeys(languages)
+
+	header := slices.Clone(fields)
+	for _, language := range sortedLanguages {
+		header = append(header, csvLanguageColumnPrefix+language)
+	}
+
+	selected, err := selectRepositoriesFields(repos, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	writer := csv.NewWriter(&body)
+	_ = writer.Write(header)
+
+	for i, repo := range repos {
+		if repo == nil {
+			continue
+		}
+
+		row := make([]string, 0, len(header))
+		for _, field := range fields {
+			if cell, ok := csvFieldCells[field]; ok {
+				row = append(row, neutralizeCsvCell(cell(repo)))
+			} else {
+				row = append(row, neutralizeCsvCell(csvCell(selected[i][field])))
+			}
+		}
+		bytesPerLanguage := make(map[string]int, len(repo.Languages))
+		for _, language := range repo.Languages {
+			bytesPerLanguage[language.Name] = language.Bytes
+		}
+		for _, language := range sortedLanguages {
+			if bytes, ok := bytesPerLanguage[language]; ok {
+				row = append(row, strconv.Itoa(bytes))
+			} else {
+				row = append(row, "")
+			}
+		}
+
+		_ = writer.Write(row)
+	}
+	writer.Flush()
+
+	return body.Bytes(), writer.Error()
+}
+
+// Characters making spreadsheets evaluate a cell as a formula
+const csvFormulaTriggers = "=+-@\t\r"
+
+// Prefixes cells starting like a formula with a quote so that spreadsheets display them as text, repository fields are
+// written by anyone publishing a repository on Github eg a description such as =HYPERLINK(...)
+func neutralizeCsvCell(cell string) string {
+	if cell != "" && strings.ContainsRune(csvFormulaTriggers, rune(cell[0])) {
+		return "'" + cell
+	}
+
+	return cell
+}
+
+// Converts a marshalled scalar to a CSV cell, null values are empty cells
+func csvCell(raw json.RawMessage) string {
+	var value string
+
+	switch {
+	case raw == nil || string(raw) == "null":
+		return ""
+	case json.Unmarshal(raw, &value) == nil:
+		return value
+	default:
+		return string(raw)
+	}
+}
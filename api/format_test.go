@@ -0,0 +1,115 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/model"
+	"github.com/LasramR/sclng-backend-test-lasramR/util"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		url      string
+		accept   string
+		expected responseFormat
+	}{
+		{"http://endpoint.io", "", jsonFormat},
+		{"http://endpoint.io", "text/csv", csvFormat},
+		{"http://endpoint.io", "application/x-ndjson", ndjsonFormat},
+		{"http://endpoint.io", "application/json;q=0.5, text/csv", csvFormat},
+		{"http://endpoint.io", "text/csv;q=0, application/x-ndjson;q=0.8", ndjsonFormat},
+		{"http://endpoint.io", "image/png", jsonFormat},
+		{"http://endpoint.io?format=ndjson", "text/csv", ndjsonFormat},
+	}
+
+	for _, c := range cases {
+		r, _ := http.NewRequest(http.MethodGet, c.url, nil)
+		r.Header.Set("Accept", c.accept)
+
+		if format, err := negotiateFormat(r); err != nil || format != c.expected {
+			t.Fatalf("%s with Accept %q should negotiate %s, got %s", c.url, c.accept, c.expected, format)
+		}
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io?format=xml", nil)
+	if _, err := negotiateFormat(r); err == nil {
+		t.Fatalf("Unsupported format query parameter should return an error")
+	}
+}
+
+func TestMarshalRepositoriesCsv(t *testing.T) {
+	repos := []*model.Repository{
+		{
+			FullName:  "owner/a",
+			License:   util.NullableJsonField[model.License]{IsNull: true},
+			Languages: model.NewLanguages(map[string]int{"Go": 10}),
+		},
+		nil,
+		{
+			FullName:  "owner/b, with comma",
+			License:   util.NullableJsonField[model.License]{Value: model.License{Key: "mit", SpdxId: "MIT"}},
+			Topics:    []string{"cli", "go"},
+			Languages: model.NewLanguages(map[string]int{"Rust": 20, "Go": 5}),
+		},
+	}
+
+	body, err := marshalRepositoriesCsv(repos, []string{"full_name", "languages", "license", "topics"})
+
+	if err != nil {
+		t.Fatalf("Should not have returned an error")
+	}
+
+	expected := strings.Join([]string{
+		"full_name,license,topics,lang:Go,lang:Rust",
+		"owner/a,,,10,",
+		"\"owner/b, with comma\",MIT,cli;go,5,20",
+		"",
+	}, "\n")
+
+	if string(body) != expected {
+		t.Fatalf("Should have flattened languages into a column per language, got %s", body)
+	}
+}
+
+func TestMarshalRepositoriesCsv_Formulas(t *testing.T) {
+	repos := []*model.Repository{
+		{
+			FullName:    "owner/a",
+			Description: `=HYPERLINK("https://evil.example","click")`,
+			Homepage:    util.NullableJsonField[string]{Value: "@SUM(1+1)"},
+			Topics:      []string{"+cmd", "go"},
+		},
+		{
+			FullName:    "owner/b",
+			Description: "\t-1+1",
+			Homepage:    util.NullableJsonField[string]{IsNull: true},
+		},
+	}
+
+	body, err := marshalRepositoriesCsv(repos, []string{"full_name", "description", "homepage", "topics"})
+
+	if err != nil {
+		t.Fatalf("Should not have returned an error")
+	}
+
+	expected := strings.Join([]string{
+		"full_name,description,homepage,topics",
+		`owner/a,"'=HYPERLINK(""https://evil.example"",""click"")",'@SUM(1+1),'+cmd;go`,
+		"owner/b,'\t-1+1,,",
+		"",
+	}, "\n")
+
+	if string(body) != expected {
+		t.Fatalf("Should have neutralized cells starting like a formula, got %s", body)
+	}
+}
+
+func TestMarshalError_Csv(t *testing.T) {
+	body, _ := marshalError(csvFormat, model.ApiError{Status: http.StatusBadRequest, Reason: []string{"invalid limit parameter"}})
+
+	if string(body) != "status,reason\n400,invalid limit parameter\n" {
+		t.Fatalf("Should have responded one row per reason, got %s", body)
+	}
+}
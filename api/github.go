@@ -1,10 +1,15 @@
 package api
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
 
@@ -18,141 +23,463 @@ import (
 	"github.com/Scalingo/go-utils/logger"
 )
 
-// Compute error object and marshal it in request response writer
-func errorFallback(w http.ResponseWriter, errs []string, status int) error {
-	response := model.ApiError{
+// Compute error object and marshal it in request response writer using the given format
+func errorFallback(w http.ResponseWriter, format responseFormat, errs []string, status int) error {
+	body, err := marshalError(format, model.ApiError{
 		Status: status,
 		Reason: errs,
+	})
+
+	if err != nil {
+		return err
 	}
+
+	w.Header().Set("Content-Type", responseFormatContentTypes[format])
 	w.WriteHeader(status)
-	return json.NewEncoder(w).Encode(response)
+
+	_, err = w.Write(body)
+	return err
+}
+
+// Compute error object of an error returned by the service layer and marshal it in request response writer.
+// Github rate limits are responded as 429 with a Retry-After header and unexpected Github responses as 502
+func serviceErrorFallback(w http.ResponseWriter, format responseFormat, err error) error {
+	var rateLimitErr *providers.RateLimitError
+	var statusErr *providers.HttpStatusError
+
+	switch {
+	case errors.As(err, &rateLimitErr):
+		w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))))
+		return errorFallback(w, format, []string{err.Error()}, http.StatusTooManyRequests)
+	case errors.As(err, &statusErr):
+		return errorFallback(w, format, []string{err.Error()}, http.StatusBadGateway)
+	case errors.Is(err, services.ErrInvalidCursor), errors.Is(err, services.ErrPageOutOfRange):
+		return errorFallback(w, format, []string{err.Error()}, http.StatusBadRequest)
+	default:
+		return errorFallback(w, format, []string{err.Error()}, http.StatusInternalServerError)
+	}
+}
+
+// Compute success object and marshal it in request response writer using the given format.
+// Pagination links are also set as a Link header, a strong ETag is computed over the marshalled object and Cache-Control / Last-Modified are derived from the freshness of the cached result,
+// responds 304 without body if the request If-None-Match header matches the ETag
+func successFallback(w http.ResponseWriter, r *http.Request, format responseFormat, grb builder.GithubRequestBuilder, cached providers.CacheEnvelope[repositories.GithubRepositoriesResult], freshFor time.Duration, cursorSecret []byte, minShare float64) error {
+	repos := cached.Value
+	repos.Repositories = foldRepositoriesLanguages(repos.Repositories, minShare)
+
+	paging := pagePagination(r, grb, repos.Total)
+	if r.URL.Query().Has("cursor") {
+		paging = cursorPagination(r, repos, cursorSecret)
+	}
+
+	var body []byte
+	var err error
+
+	switch format {
+	case csvFormat:
+		body, err = marshalRepositoriesCsv(repos.Repositories, grb.Selection())
+	case ndjsonFormat:
+		body, err = marshalRepositoriesNdjson(repos.Repositories, grb.Selection())
+	default:
+		body, err = marshalListResponse(r, grb, repos, paging)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	etag := fmt.Sprintf("\"%x\"", sha256.Sum256(body))
+	maxAge := max(time.Until(cached.FreshUntil)/time.Second, 0)
+
+	w.Header().Set("Vary", "Accept")
+	w.Header().Set("Link", paging.linkHeader())
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", maxAge))
+	w.Header().Set("Last-Modified", cached.FreshUntil.Add(-freshFor).UTC().Format(http.TimeFormat))
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", responseFormatContentTypes[format])
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(body)
+	return err
 }
 
-// Compute success object and marshal it in request response writer
-func successFallback(w http.ResponseWriter, r *http.Request, repos repositories.GithubRepositoriesResult) error {
-	response := model.ApiListResponse[[]*model.Repository]{
+// Marshals repos as a list response echoing the effective sorting of grb and the pagination
+func marshalListResponse(r *http.Request, grb builder.GithubRequestBuilder, repos repositories.GithubRepositoriesResult, paging pagination) ([]byte, error) {
+	sort, order := grb.Sorting()
+
+	var content any = repos.Repositories
+	if r.URL.Query().Has("fields") {
+		selected, err := selectRepositoriesFields(repos.Repositories, grb.Selection())
+		if err != nil {
+			return nil, err
+		}
+		content = selected
+	}
+
+	response := model.ApiListResponse[any]{
 		TotalCount:       repos.Total,
 		Count:            len(repos.Repositories),
-		Content:          repos.Repositories,
+		Content:          content,
 		IncompleteResult: repos.IncompleteResult,
-		Page:             0,
+		Errors:           append(make([]model.RepositoryError, 0, len(repos.Errors)), repos.Errors...),
+		Sort:             sort,
+		Order:            order,
+		Page:             paging.page,
+		TotalPages:       paging.totalPages,
+		HasNext:          paging.hasNext(),
+		HasPrevious:      paging.hasPrevious(),
 		Previous: util.NullableJsonField[string]{
-			IsNull: r.URL.Query().Get("page") == "",
-			Value:  util.PreviousFullUrlFromRequest(r),
+			IsNull: !paging.hasPrevious(),
+			Value:  paging.previous,
+		},
+		Next: util.NullableJsonField[string]{
+			IsNull: !paging.hasNext(),
+			Value:  paging.next,
 		},
-		Next: util.NextFullUrlFromRequest(r),
 	}
 
+	var body bytes.Buffer
+	err := json.NewEncoder(&body).Encode(response)
+
+	return body.Bytes(), err
+}
+
+// Returns copies of repos whose languages holding less than minShare percents are folded, repos are shared with other requests and must not be modified
+func foldRepositoriesLanguages(repos []*model.Repository, minShare float64) []*model.Repository {
+	if minShare == 0 {
+		return repos
+	}
+
+	folded := make([]*model.Repository, len(repos))
+	for i, repo := range repos {
+		if repo == nil {
+			continue
+		}
+
+		copied := *repo
+		copied.Languages = repo.Languages.Fold(minShare)
+		folded[i] = &copied
+	}
+
+	return folded
+}
+
+// Restricts each marshalled repository to the fields of selection, failed aggregations stay null
+func selectRepositoriesFields(repos []*model.Repository, selection []string) ([]map[string]json.RawMessage, error) {
+	selected := make([]map[string]json.RawMessage, len(repos))
+
+	for i, repo := range repos {
+		if repo == nil {
+			continue
+		}
+
+		marshalled, err := json.Marshal(repo)
+		if err != nil {
+			return nil, err
+		}
+
+		var fields map[string]json.RawMessage
+		if err = json.Unmarshal(marshalled, &fields); err != nil {
+			return nil, err
+		}
+
+		for field := range fields {
+			if !slices.Contains(selection, field) {
+				delete(fields, field)
+			}
+		}
+		selected[i] = fields
+	}
+
+	return selected, nil
+}
+
+// Describes if an If-None-Match header matches etag, using the weak comparison as described by RFC 9110
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Marshal a single repository in request response writer
+func repositorySuccessFallback(w http.ResponseWriter, repo *model.Repository) error {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	return json.NewEncoder(w).Encode(repo)
+}
+
+// Marshal a repository whose fields failed along with their errors in request response writer
+func partialRepositorySuccessFallback(w http.ResponseWriter, repo *model.Repository, repositoryErrors []model.RepositoryError) error {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	return json.NewEncoder(w).Encode(model.ApiRepositoryResponse{Repository: repo, Errors: repositoryErrors})
+}
+
+// Marshal aggregated languages stats in request response writer
+func statsSuccessFallback(w http.ResponseWriter, stats model.LanguagesStats) error {
 	w.Header().Add("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
-	return json.NewEncoder(w).Encode(response)
+	return json.NewEncoder(w).Encode(stats)
+}
+
+// Marshal the rate limit state in request response writer
+func rateLimitSuccessFallback(w http.ResponseWriter, rateLimit model.RateLimit) error {
+	w.Header().Add("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	return json.NewEncoder(w).Encode(rateLimit)
 }
 
 // /repos HTTP handle
 func GitHubProjectsHandler(
 	githubService services.GithubService,
 	cacheProvider providers.CacheProvider,
+	lockProvider providers.LockProvider,
 	cacheDurationInMin time.Duration,
+	staleDurationInMin time.Duration,
+	cursorSecret []byte,
 	apiVersion version.GithubAPIVersion,
 ) util.ScalingoHandlerFunc {
+	// Shared by every request so that concurrent identical requests are fetched once
+	coalescer := util.NewCoalescer[providers.CacheEnvelope[repositories.GithubRepositoriesResult]]()
+
 	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) error {
 		ctx := r.Context()
 		log := logger.Get(ctx)
 
+		// Negotiated first so that errors are also responded in the requested format
+		format, err := negotiateFormat(r)
+		if err != nil {
+			return errorFallback(w, jsonFormat, []string{err.Error()}, http.StatusBadRequest)
+		}
+
+		// Only respond to GET
+		if r.Method != http.MethodGet {
+			return errorFallback(w, format, []string{"GET only endpoint"}, http.StatusMethodNotAllowed)
+		}
+
+		// Cursors are signed so that clients can't forge walk positions
+		cursor := r.URL.Query().Get("cursor")
+		if cursor != "" {
+			verified, err := util.Verify(cursorSecret, cursor)
+			if err != nil {
+				return errorFallback(w, format, []string{services.ErrInvalidCursor.Error()}, http.StatusBadRequest)
+			}
+			cursor = verified
+		}
+
+		requestUrl := util.FullUrlFromRequest(r)
+		fetch := func(grb builder.GithubRequestBuilder) func(ctx context.Context) (repositories.GithubRepositoriesResult, error) {
+			return func(ctx context.Context) (repositories.GithubRepositoriesResult, error) {
+				// Walking past the first 1000 results is opted in with a cursor, empty for the first page
+				if r.URL.Query().Has("cursor") {
+					return githubService.GetGithubProjectsAfter(ctx, grb, cursor)
+				}
+
+				return githubService.GetGithubProjectsWithStats(ctx, grb)
+			}
+		}
+
+		grb, status, reasons := githubRequestBuilderFromQuery(apiVersion, r.URL.Query())
+
+		if len(reasons) != 0 {
+			log.Error(strings.Join(reasons, ", "))
+			return errorFallback(w, format, reasons, status)
+		}
+
+		minShare, err := minShareFromQuery(r.URL.Query())
+		if err != nil {
+			return errorFallback(w, format, []string{err.Error()}, http.StatusBadRequest)
+		}
+
+		// Returns if successful cache read from requestUrl
+		cached, cacheErr := providers.GetEnveloped[repositories.GithubRepositoriesResult](ctx, cacheProvider, requestUrl)
+		if cacheErr == nil && cached.IsFresh(time.Now()) {
+			w.Header().Set("X-Cache", "HIT")
+			return successFallback(w, r, format, grb, cached, time.Minute*cacheDurationInMin, cursorSecret, minShare)
+		}
+
+		// Stale results are responded immediately while being refreshed in background
+		if cacheErr == nil {
+			go func() {
+				_, err := coalescedFetch(context.WithoutCancel(ctx), coalescer, lockProvider, cacheProvider, time.Minute*cacheDurationInMin, time.Minute*staleDurationInMin, requestUrl, fetch(grb))
+				if err != nil {
+					log.WithError(err).Error("Fail to refresh stale repositories")
+				}
+			}()
+
+			w.Header().Set("X-Cache", "STALE")
+			return successFallback(w, r, format, grb, cached, time.Minute*cacheDurationInMin, cursorSecret, minShare)
+		}
+
+		// GIVE ME THESE REPOSITORIES, fetched and cached once for concurrent identical requests
+		fetched, err := coalescedFetch(ctx, coalescer, lockProvider, cacheProvider, time.Minute*cacheDurationInMin, time.Minute*staleDurationInMin, requestUrl, fetch(grb))
+
+		if err != nil {
+			log.WithError(err).Error(err)
+			return serviceErrorFallback(w, format, err)
+		}
+
+		w.Header().Set("X-Cache", "MISS")
+		return successFallback(w, r, format, grb, fetched, time.Minute*cacheDurationInMin, cursorSecret, minShare)
+	}
+
+}
+
+// /repos/{owner}/{name} HTTP handle
+func GitHubProjectHandler(
+	githubService services.GithubService,
+	cacheProvider providers.CacheProvider,
+	cacheDurationInMin time.Duration,
+	apiVersion version.GithubAPIVersion,
+) util.ScalingoHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, vars map[string]string) error {
+		ctx := r.Context()
+		log := logger.Get(ctx)
+
 		// Only respond to GET
 		if r.Method != http.MethodGet {
-			return errorFallback(w, []string{"GET only endpoint"}, http.StatusMethodNotAllowed)
+			return errorFallback(w, jsonFormat, []string{"GET only endpoint"}, http.StatusMethodNotAllowed)
+		}
+
+		owner, name := vars["owner"], vars["name"]
+		if owner == "" || name == "" {
+			return errorFallback(w, jsonFormat, []string{"owner and name are required"}, http.StatusBadRequest)
 		}
 
 		requestUrl := util.FullUrlFromRequest(r)
-		var repos repositories.GithubRepositoriesResult = repositories.GithubRepositoriesResult{}
+		var repo model.Repository
 		// Returns if successful cache read from requestUrl
-		if err := cacheProvider.GetUnmarshalled(ctx, requestUrl, &repos); err == nil {
-			return successFallback(w, r, repos)
+		if err := cacheProvider.GetUnmarshalled(ctx, requestUrl, &repo); err == nil {
+			return repositorySuccessFallback(w, &repo)
 		}
 
 		grb, err := builder.NewGithubRequestBuilder(apiVersion)
 
 		if err != nil {
 			log.WithError(err).Error(err)
-			return errorFallback(w, []string{err.Error()}, http.StatusServiceUnavailable)
+			return errorFallback(w, jsonFormat, []string{err.Error()}, http.StatusServiceUnavailable)
 		}
 
-		queryParams := r.URL.Query()
+		result, err := githubService.GetGithubProject(ctx, grb, owner, name)
 
-		// Setting results limit if set in query
-		limit := queryParams.Get("limit")
-		if limit != "" {
-			queryParams.Del("limit")
-			parsedLimit, err := strconv.Atoi(limit)
-			if err != nil {
-				err = errors.New("invalid limit parameter")
-				log.WithError(err).Error(err)
-				return errorFallback(w, []string{err.Error()}, http.StatusBadRequest)
-			}
-
-			if err := grb.Limit(parsedLimit); err != nil {
-				log.WithError(err).Error(err)
-				return errorFallback(w, []string{err.Error()}, http.StatusBadRequest)
-			}
+		var enrichmentErr *repositories.EnrichmentError
+		if errors.Is(err, repositories.ErrRepositoryNotFound) {
+			return errorFallback(w, jsonFormat, []string{fmt.Sprintf("repository %s/%s not found", owner, name)}, http.StatusNotFound)
+		} else if err != nil && (result == nil || !errors.As(err, &enrichmentErr)) {
+			log.WithError(err).Error(err)
+			return serviceErrorFallback(w, jsonFormat, err)
+		} else if err != nil {
+			// Partial repositories are not cached so that next requests fetch their failed fields again
+			log.WithError(err).Warn("Responding a partial repository")
+			return partialRepositorySuccessFallback(w, result, repositories.RepositoryErrorsOf(result, err))
 		}
 
-		// Setting results page if set in query
-		page := queryParams.Get("page")
-		if page != "" {
-			queryParams.Del("page")
-			parsedPage, err := strconv.Atoi(page)
-			if err != nil {
-				err = errors.New("invalid page parameter")
-				log.WithError(err).Error(err)
-				return errorFallback(w, []string{err.Error()}, http.StatusBadRequest)
-			}
+		// Set in cache
+		_ = cacheProvider.SetMarshalled(ctx, requestUrl, result, time.Minute*cacheDurationInMin)
 
-			if err := grb.Page(parsedPage); err != nil {
-				log.WithError(err).Error(err)
-				return errorFallback(w, []string{err.Error()}, http.StatusBadRequest)
-			}
+		return repositorySuccessFallback(w, result)
+	}
+}
+
+// Query parameters of /repos that /stats/languages rejects
+var languagesStatsUnsupportedParams = []string{"cursor", "expand", "fields", "format", "min_share"}
+
+// /stats/languages HTTP handle
+func GitHubLanguagesStatsHandler(
+	githubService services.GithubService,
+	cacheProvider providers.CacheProvider,
+	cacheDurationInMin time.Duration,
+	apiVersion version.GithubAPIVersion,
+) util.ScalingoHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) error {
+		ctx := r.Context()
+		log := logger.Get(ctx)
+
+		// Only respond to GET
+		if r.Method != http.MethodGet {
+			return errorFallback(w, jsonFormat, []string{"GET only endpoint"}, http.StatusMethodNotAllowed)
 		}
 
-		// Setting Github query sorting order if set in query
-		sort := queryParams.Get("sort")
-		if sort != "" {
-			queryParams.Del("sort")
-			err = grb.Sort(sort)
-			if err != nil {
-				log.WithError(err).Error(err)
-				return errorFallback(w, []string{err.Error()}, http.StatusBadRequest)
-			}
+		requestUrl := util.FullUrlFromRequest(r)
+		var stats model.LanguagesStats
+		// Returns if successful cache read from requestUrl
+		if err := cacheProvider.GetUnmarshalled(ctx, requestUrl, &stats); err == nil {
+			return statsSuccessFallback(w, stats)
 		}
-		// Consumming leftovers query parameters
-		queryParamsErrors := make([]string, 0, len(queryParams))
-		for k, v := range queryParams {
-			err := grb.With(k, strings.Join(v, " "))
 
-			if err != nil {
-				queryParamsErrors = append(queryParamsErrors, err.Error())
+		// Stats are computed over the languages of the matching repositories only, presentation and enrichment parameters would be ignored
+		for _, param := range languagesStatsUnsupportedParams {
+			if r.URL.Query().Has(param) {
+				return errorFallback(w, jsonFormat, []string{fmt.Sprintf("%s parameter is not supported by this endpoint", param)}, http.StatusBadRequest)
 			}
 		}
 
-		// If we collected errors
-		if len(queryParamsErrors) != 0 {
-			log.WithError(err).Error(err)
-			return errorFallback(w, queryParamsErrors, http.StatusBadRequest)
+		grb, status, reasons := githubRequestBuilderFromQuery(apiVersion, r.URL.Query())
+
+		if len(reasons) != 0 {
+			log.Error(strings.Join(reasons, ", "))
+			return errorFallback(w, jsonFormat, reasons, status)
 		}
 
-		// GIVE ME THESE REPOSITORIES
-		repos, err = githubService.GetGithubProjectsWithStats(ctx, grb)
+		stats, err := githubService.GetGithubLanguagesStats(ctx, grb)
 
 		if err != nil {
 			log.WithError(err).Error(err)
-			return errorFallback(w, []string{err.Error()}, http.StatusInternalServerError)
+			return serviceErrorFallback(w, jsonFormat, err)
 		}
 
 		// Set in cache
-		_ = cacheProvider.SetMarshalled(ctx, requestUrl, repos, time.Minute*cacheDurationInMin)
+		_ = cacheProvider.SetMarshalled(ctx, requestUrl, stats, time.Minute*cacheDurationInMin)
 
-		return successFallback(w, r, repos)
+		return statsSuccessFallback(w, stats)
 	}
+}
+
+// /rate_limit HTTP handle
+func GitHubRateLimitHandler(
+	githubService services.GithubService,
+	apiVersion version.GithubAPIVersion,
+) util.ScalingoHandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) error {
+		ctx := r.Context()
+		log := logger.Get(ctx)
+
+		// Only respond to GET
+		if r.Method != http.MethodGet {
+			return errorFallback(w, jsonFormat, []string{"GET only endpoint"}, http.StatusMethodNotAllowed)
+		}
+
+		grb, err := builder.NewGithubRequestBuilder(apiVersion)
+
+		if err != nil {
+			log.WithError(err).Error(err)
+			return errorFallback(w, jsonFormat, []string{err.Error()}, http.StatusServiceUnavailable)
+		}
+
+		// Rate limit state is never cached as it changes on every Github request
+		rateLimit, err := githubService.GetGithubRateLimit(ctx, grb)
 
+		if err != nil {
+			log.WithError(err).Error(err)
+			return serviceErrorFallback(w, jsonFormat, err)
+		}
+
+		return rateLimitSuccessFallback(w, rateLimit)
+	}
 }
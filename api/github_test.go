@@ -1,14 +1,20 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/LasramR/sclng-backend-test-lasramR/model"
 	"github.com/LasramR/sclng-backend-test-lasramR/model/version"
+	"github.com/LasramR/sclng-backend-test-lasramR/providers"
+	"github.com/LasramR/sclng-backend-test-lasramR/repositories"
 	"github.com/LasramR/sclng-backend-test-lasramR/util"
 )
 
@@ -16,7 +22,10 @@ func TestGitHubProjectsHandler_WrongMethod(t *testing.T) {
 	handler := GitHubProjectsHandler(
 		MockGitHubService{},
 		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
 		5,
+		5,
+		[]byte("secret"),
 		version.GITHUB_API_2022_11_28,
 	)
 
@@ -39,7 +48,10 @@ func TestGitHubProjectsHandler_Valid(t *testing.T) {
 	handler := GitHubProjectsHandler(
 		&mgs,
 		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
 		5,
+		[]byte("secret"),
 		version.GITHUB_API_2022_11_28,
 	)
 
@@ -58,7 +70,15 @@ func TestGitHubProjectsHandler_Valid(t *testing.T) {
 		Count:            1,
 		Content:          result.Repositories,
 		IncompleteResult: false,
-		Next:             util.NextFullUrlFromRequest(r),
+		Errors:           []model.RepositoryError{},
+		Sort:             "best-match",
+		Order:            "desc",
+		Page:             1,
+		TotalPages:       1,
+		Next: util.NullableJsonField[string]{
+			IsNull: true,
+			Value:  "",
+		},
 		Previous: util.NullableJsonField[string]{
 			IsNull: true,
 			Value:  "",
@@ -74,12 +94,331 @@ func TestGitHubProjectsHandler_Valid(t *testing.T) {
 	}
 }
 
+func TestGitHubProjectsHandler_Sorting(t *testing.T) {
+	mgs := MockGitHubService{}
+	handler := GitHubProjectsHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
+		5,
+		[]byte("secret"),
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io?sort=help-wanted-issues&order=asc", nil)
+	w := NewMockResponseWriter()
+
+	if err := handler(w, r, nil); err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	var response model.ApiListResponse[[]*model.Repository]
+	_ = json.Unmarshal(w.Buffer.Bytes(), &response)
+
+	if w.StatusCode != http.StatusOK || response.Sort != "help-wanted-issues" || response.Order != "asc" {
+		t.Fatalf("Should have echoed the effective sort and order")
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "http://endpoint.io?order=up", nil)
+	w = NewMockResponseWriter()
+	_ = handler(w, r, nil)
+
+	if w.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Should have responded with status 400 for an unsupported order")
+	}
+}
+
+func TestGitHubProjectsHandler_Cursor(t *testing.T) {
+	mgs := MockGitHubService{}
+	handler := GitHubProjectsHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
+		5,
+		[]byte("secret"),
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io?cursor=", nil)
+	w := NewMockResponseWriter()
+
+	if err := handler(w, r, nil); err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	var response model.ApiListResponse[[]*model.Repository]
+	_ = json.Unmarshal(w.Buffer.Bytes(), &response)
+
+	if response.Next.IsNull || response.Next.Value != util.FullUrlFromRequestWith(r, "cursor", util.Sign([]byte("secret"), "next")) {
+		t.Fatalf("Next should point to the signed next cursor")
+	}
+
+	if response.Page != 1 || !response.HasNext || response.HasPrevious {
+		t.Fatalf("Should have described the first page of the walk")
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, response.Next.Value, nil)
+	w = NewMockResponseWriter()
+	_ = handler(w, r, nil)
+	_ = json.Unmarshal(w.Buffer.Bytes(), &response)
+
+	if w.StatusCode != http.StatusOK || response.Page != 2 || !response.Next.IsNull {
+		t.Fatalf("Should have responded with the last page of the walk")
+	}
+
+	for _, query := range []string{"cursor=next", "cursor=" + util.Sign([]byte("other secret"), "next"), "cursor=next&page=2", "cursor=" + util.Sign([]byte("secret"), "next") + "&limit=10"} {
+		r, _ = http.NewRequest(http.MethodGet, "http://endpoint.io?"+query, nil)
+		w = NewMockResponseWriter()
+		_ = handler(w, r, nil)
+
+		if w.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Should have responded with status 400 for %s", query)
+		}
+	}
+}
+
+func TestGitHubProjectsHandler_Pagination(t *testing.T) {
+	mgs := MockGitHubService{total: 95}
+	handler := GitHubProjectsHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
+		5,
+		[]byte("secret"),
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io?limit=10&page=3", nil)
+	w := NewMockResponseWriter()
+
+	if err := handler(w, r, nil); err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	var response model.ApiListResponse[[]*model.Repository]
+	_ = json.Unmarshal(w.Buffer.Bytes(), &response)
+
+	if response.Page != 3 || response.TotalPages != 10 || !response.HasNext || !response.HasPrevious {
+		t.Fatalf("Should have derived the pagination from the total count and the limit")
+	}
+
+	if response.Previous.Value != util.FullUrlFromRequestWith(r, "page", "2") || response.Next.Value != util.FullUrlFromRequestWith(r, "page", "4") {
+		t.Fatalf("Previous and next should point to the surrounding pages")
+	}
+
+	expectedLink := fmt.Sprintf(
+		"<%s>; rel=\"first\", <%s>; rel=\"prev\", <%s>; rel=\"next\", <%s>; rel=\"last\"",
+		util.FullUrlFromRequestWith(r, "page", "1"),
+		util.FullUrlFromRequestWith(r, "page", "2"),
+		util.FullUrlFromRequestWith(r, "page", "4"),
+		util.FullUrlFromRequestWith(r, "page", "10"),
+	)
+	if w.Header().Get("Link") != expectedLink {
+		t.Fatalf("Should have set the Link header, got %s", w.Header().Get("Link"))
+	}
+
+	// Pages past the end point back to the last page
+	r, _ = http.NewRequest(http.MethodGet, "http://endpoint.io?limit=10&page=12", nil)
+	w = NewMockResponseWriter()
+	_ = handler(w, r, nil)
+	_ = json.Unmarshal(w.Buffer.Bytes(), &response)
+
+	if response.HasNext || response.Previous.Value != util.FullUrlFromRequestWith(r, "page", "10") {
+		t.Fatalf("Previous of a page past the end should be the last page")
+	}
+}
+
+func TestGitHubProjectsHandler_Fields(t *testing.T) {
+	mgs := MockGitHubService{}
+	handler := GitHubProjectsHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
+		5,
+		[]byte("secret"),
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io?fields=full_name,license", nil)
+	w := NewMockResponseWriter()
+
+	if err := handler(w, r, nil); err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	var response model.ApiListResponse[[]map[string]any]
+	_ = json.Unmarshal(w.Buffer.Bytes(), &response)
+
+	expected := []map[string]any{{"full_name": "fmuiin14/BlazingTool", "license": map[string]any{"key": "mit", "spdx_id": "MIT", "name": "MIT License"}}}
+	if w.StatusCode != http.StatusOK || !reflect.DeepEqual(response.Content, expected) {
+		t.Fatalf("Should have only responded the requested fields, got %v", response.Content)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "http://endpoint.io?fields=full_name,stargazers", nil)
+	w = NewMockResponseWriter()
+	_ = handler(w, r, nil)
+
+	if w.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Should have responded with status 400 for an unsupported field")
+	}
+}
+
+func TestGitHubProjectsHandler_Formats(t *testing.T) {
+	mgs := MockGitHubService{}
+	handler := GitHubProjectsHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
+		5,
+		[]byte("secret"),
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io?fields=full_name,size", nil)
+	r.Header.Set("Accept", "text/csv")
+	w := NewMockResponseWriter()
+
+	if err := handler(w, r, nil); err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	if w.StatusCode != http.StatusOK || w.Header().Get("Content-Type") != "text/csv; charset=utf-8" || w.Buffer.String() != "full_name,size\nfmuiin14/BlazingTool,156464\n" {
+		t.Fatalf("Should have responded repositories as CSV, got %s", w.Buffer.String())
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "http://endpoint.io?format=ndjson&fields=full_name", nil)
+	w = NewMockResponseWriter()
+	_ = handler(w, r, nil)
+
+	if w.Header().Get("Content-Type") != "application/x-ndjson" || w.Buffer.String() != "{\"full_name\":\"fmuiin14/BlazingTool\"}\n" {
+		t.Fatalf("Should have responded one repository per line, got %s", w.Buffer.String())
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "http://endpoint.io?format=csv&limit=pouet", nil)
+	w = NewMockResponseWriter()
+	_ = handler(w, r, nil)
+
+	if w.StatusCode != http.StatusBadRequest || w.Buffer.String() != "status,reason\n400,invalid limit parameter\n" {
+		t.Fatalf("Should have responded the error as CSV, got %s", w.Buffer.String())
+	}
+}
+
+func TestGitHubProjectsHandler_MinShare(t *testing.T) {
+	mgs := MockGitHubService{}
+	handler := GitHubProjectsHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
+		5,
+		[]byte("secret"),
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io?min_share=20", nil)
+	w := NewMockResponseWriter()
+
+	if err := handler(w, r, nil); err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	var response model.ApiListResponse[[]model.Repository]
+	_ = json.Unmarshal(w.Buffer.Bytes(), &response)
+
+	expected := model.Languages{
+		{Name: "JavaScript", Bytes: 1548, Percentage: 86.1, Rank: 1},
+		{Name: model.OTHER_LANGUAGE, Bytes: 250, Percentage: 13.9, Rank: 2},
+	}
+	if w.StatusCode != http.StatusOK || len(response.Content) != 1 || !reflect.DeepEqual(response.Content[0].Languages, expected) {
+		t.Fatalf("Should have folded languages under min_share, got %v", response.Content)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "http://endpoint.io?min_share=120", nil)
+	w = NewMockResponseWriter()
+	_ = handler(w, r, nil)
+
+	if w.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Should have responded with status 400 for a min_share above 100")
+	}
+}
+
+func TestGitHubProjectsHandler_MinShareLanguagesFailure(t *testing.T) {
+	mgs := MockGitHubService{
+		repositoryErrors: []model.RepositoryError{{Index: 0, FullName: "fmuiin14/BlazingTool", Enrichment: "languages", Reason: "timeout"}},
+	}
+	handler := GitHubProjectsHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
+		5,
+		[]byte("secret"),
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io?min_share=20", nil)
+	w := NewMockResponseWriter()
+
+	if err := handler(w, r, nil); err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	if !strings.Contains(w.Buffer.String(), `"languages":null`) {
+		t.Fatalf("Failed languages should stay null once folded, got %s", w.Buffer.String())
+	}
+}
+
+func TestGitHubProjectsHandler_Errors(t *testing.T) {
+	mgs := MockGitHubService{
+		repositoryErrors: []model.RepositoryError{{Index: 0, FullName: "fmuiin14/BlazingTool", Enrichment: "releases", Reason: "timeout"}},
+	}
+	handler := GitHubProjectsHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
+		5,
+		[]byte("secret"),
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io", nil)
+	w := NewMockResponseWriter()
+
+	if err := handler(w, r, nil); err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	var response model.ApiListResponse[[]*model.Repository]
+	_ = json.Unmarshal(w.Buffer.Bytes(), &response)
+
+	if !response.IncompleteResult || !reflect.DeepEqual(response.Errors, mgs.repositoryErrors) || response.Content[0] == nil {
+		t.Fatalf("Should have responded the partial repository along with its errors, got %v", response.Errors)
+	}
+
+	mgs.repositoryErrors = nil
+	w = NewMockResponseWriter()
+	_ = handler(w, r, nil)
+
+	if !strings.Contains(w.Buffer.String(), `"errors":[]`) {
+		t.Fatalf("Should have responded an empty errors array, got %s", w.Buffer.String())
+	}
+}
+
 func TestGitHubProjectsHandler_UnvalidLimit(t *testing.T) {
 	mgs := MockGitHubService{}
 	handler := GitHubProjectsHandler(
 		&mgs,
 		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
 		5,
+		5,
+		[]byte("secret"),
 		version.GITHUB_API_2022_11_28,
 	)
 
@@ -102,7 +441,10 @@ func TestGitHubProjectsHandler_UnvalidPage(t *testing.T) {
 	handler := GitHubProjectsHandler(
 		&mgs,
 		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
 		5,
+		[]byte("secret"),
 		version.GITHUB_API_2022_11_28,
 	)
 
@@ -125,7 +467,10 @@ func TestGitHubProjectsHandler_UnsupportedQueryParam(t *testing.T) {
 	handler := GitHubProjectsHandler(
 		&mgs,
 		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
 		5,
+		[]byte("secret"),
 		version.GITHUB_API_2022_11_28,
 	)
 
@@ -148,7 +493,10 @@ func TestGitHubProjectsHandler_UnsupportedGithubApiVersion(t *testing.T) {
 	handler := GitHubProjectsHandler(
 		&mgs,
 		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
 		5,
+		5,
+		[]byte("secret"),
 		version.GithubAPIVersion("unsupported"),
 	)
 
@@ -171,7 +519,10 @@ func TestGitHubProjectsHandler_GithubServiceError(t *testing.T) {
 	handler := GitHubProjectsHandler(
 		&mgs,
 		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
 		5,
+		[]byte("secret"),
 		version.GITHUB_API_2022_11_28,
 	)
 
@@ -188,3 +539,321 @@ func TestGitHubProjectsHandler_GithubServiceError(t *testing.T) {
 		t.Fatalf("Should have responded with status StatusInternalServerError")
 	}
 }
+
+func TestGitHubProjectsHandler_StaleWhileRevalidate(t *testing.T) {
+	mgs := MockGitHubService{}
+	cacheProvider := providers.NewMemoryCacheProvider(10, 1<<20)
+	handler := GitHubProjectsHandler(
+		&mgs,
+		cacheProvider,
+		nil,
+		5,
+		5,
+		[]byte("secret"),
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io", nil)
+	requestUrl := util.FullUrlFromRequest(r)
+
+	// Sets an entry that is no longer fresh but still within its stale window
+	_ = cacheProvider.SetMarshalled(r.Context(), requestUrl, providers.CacheEnvelope[repositories.GithubRepositoriesResult]{
+		FreshUntil: time.Now().Add(-time.Minute),
+		StaleUntil: time.Now().Add(time.Minute),
+	}, 0)
+
+	w := NewMockResponseWriter()
+
+	if err := handler(w, r, nil); err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	if w.StatusCode != http.StatusOK || w.Headers.Get("X-Cache") != "STALE" {
+		t.Fatalf("Stale entry should have been responded immediately")
+	}
+
+	// Waits for the background refresh
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if envelope, err := providers.GetEnveloped[repositories.GithubRepositoriesResult](r.Context(), cacheProvider, requestUrl); err == nil && envelope.IsFresh(time.Now()) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("Stale entry should have been refreshed in background")
+}
+
+func TestGitHubProjectsHandler_NotModified(t *testing.T) {
+	mgs := MockGitHubService{}
+	handler := GitHubProjectsHandler(
+		&mgs,
+		providers.NewMemoryCacheProvider(10, 1<<20),
+		nil,
+		5,
+		5,
+		[]byte("secret"),
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io", nil)
+	w := NewMockResponseWriter()
+
+	if err := handler(w, r, nil); err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	etag := w.Headers.Get("ETag")
+	if etag == "" || w.Headers.Get("Cache-Control") == "max-age=0" || w.Headers.Get("Last-Modified") == "" {
+		t.Fatalf("Fetched result should have been responded with cache headers")
+	}
+
+	// Same request served from cache with the ETag of the previous response
+	r.Header.Set("If-None-Match", etag)
+	w = NewMockResponseWriter()
+
+	if err := handler(w, r, nil); err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	if w.StatusCode != http.StatusNotModified || w.Buffer.Len() != 0 {
+		t.Fatalf("Should have responded with status 304 without body")
+	}
+
+	if w.Headers.Get("ETag") != etag || w.Headers.Get("X-Cache") != "HIT" {
+		t.Fatalf("Cached result should have been responded with the same ETag")
+	}
+}
+
+func TestGitHubProjectHandler_Valid(t *testing.T) {
+	mgs := MockGitHubService{}
+	handler := GitHubProjectHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		5,
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io/repos/fmuiin14/BlazingTool", nil)
+	w := NewMockResponseWriter()
+
+	err := handler(w, r, map[string]string{"owner": "fmuiin14", "name": "BlazingTool"})
+
+	if err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	result, _ := mgs.GetGithubProject(r.Context(), nil, "fmuiin14", "BlazingTool")
+	expected, _ := json.Marshal(result)
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("Should have responded with status 200")
+	}
+
+	if !reflect.DeepEqual(w.Buffer.Bytes()[:len(w.Buffer.Bytes())-1], expected) {
+		t.Fatalf("Expected should have been written in response writter")
+	}
+}
+
+func TestGitHubProjectHandler_Partial(t *testing.T) {
+	mgs := MockGitHubService{
+		repositoryErrors: []model.RepositoryError{{Index: 0, FullName: "fmuiin14/BlazingTool", Enrichment: "languages", Reason: "timeout"}},
+	}
+	cacheProvider := providers.NewMemoryCacheProvider(10, 64*1024)
+	handler := GitHubProjectHandler(
+		&mgs,
+		cacheProvider,
+		5,
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io/repos/fmuiin14/BlazingTool", nil)
+	w := NewMockResponseWriter()
+
+	if err := handler(w, r, map[string]string{"owner": "fmuiin14", "name": "BlazingTool"}); err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	var response model.ApiRepositoryResponse
+	_ = json.Unmarshal(w.Buffer.Bytes(), &response)
+
+	if w.StatusCode != http.StatusOK || response.Repository == nil || response.FullName != "fmuiin14/BlazingTool" || response.Languages != nil {
+		t.Fatalf("Should have responded the partial repository, got %s", w.Buffer.String())
+	}
+
+	if !reflect.DeepEqual(response.Errors, mgs.repositoryErrors) {
+		t.Fatalf("Should have responded the failures of the repository fields, got %v", response.Errors)
+	}
+
+	var cached model.Repository
+	if err := cacheProvider.GetUnmarshalled(context.Background(), util.FullUrlFromRequest(r), &cached); err == nil {
+		t.Fatalf("Partial repository should not have been cached")
+	}
+}
+
+func TestGitHubProjectHandler_NotFound(t *testing.T) {
+	mgs := MockGitHubService{err: repositories.ErrRepositoryNotFound}
+	handler := GitHubProjectHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		5,
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io/repos/fmuiin14/Unknown", nil)
+	w := NewMockResponseWriter()
+
+	err := handler(w, r, map[string]string{"owner": "fmuiin14", "name": "Unknown"})
+
+	if err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	if w.StatusCode != http.StatusNotFound {
+		t.Fatalf("Should have responded with status 404")
+	}
+}
+
+func TestGitHubLanguagesStatsHandler_Valid(t *testing.T) {
+	mgs := MockGitHubService{}
+	handler := GitHubLanguagesStatsHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		5,
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io/stats/languages?org=Scalingo", nil)
+	w := NewMockResponseWriter()
+
+	err := handler(w, r, nil)
+
+	if err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	result, _ := mgs.GetGithubLanguagesStats(r.Context(), nil)
+	expected, _ := json.Marshal(result)
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("Should have responded with status 200")
+	}
+
+	if !reflect.DeepEqual(w.Buffer.Bytes()[:len(w.Buffer.Bytes())-1], expected) {
+		t.Fatalf("Expected should have been written in response writter")
+	}
+}
+
+func TestGitHubLanguagesStatsHandler_UnsupportedQueryParam(t *testing.T) {
+	mgs := MockGitHubService{}
+	handler := GitHubLanguagesStatsHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		5,
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io/stats/languages?unsupported=ohno", nil)
+	w := NewMockResponseWriter()
+
+	err := handler(w, r, nil)
+
+	if err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	if w.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Should have responded with status 400")
+	}
+
+	for _, query := range []string{"expand=contributors", "cursor=", "format=csv", "min_share=10", "fields=full_name"} {
+		r, _ = http.NewRequest(http.MethodGet, "http://endpoint.io/stats/languages?org=Scalingo&"+query, nil)
+		w = NewMockResponseWriter()
+		_ = handler(w, r, nil)
+
+		if w.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Should have rejected %s with status 400, got %d", query, w.StatusCode)
+		}
+	}
+}
+
+func TestGitHubProjectsHandler_GithubRateLimitExceeded(t *testing.T) {
+	mgs := MockGitHubService{err: &providers.RateLimitError{RetryAfter: 90 * time.Second}}
+	handler := GitHubProjectsHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
+		5,
+		[]byte("secret"),
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io", nil)
+	w := NewMockResponseWriter()
+
+	err := handler(w, r, nil)
+
+	if err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	if w.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Should have responded with status StatusTooManyRequests")
+	}
+
+	if w.Headers.Get("Retry-After") != "90" {
+		t.Fatalf("Should have responded with a Retry-After header")
+	}
+}
+
+func TestGitHubProjectsHandler_GithubUnexpectedStatus(t *testing.T) {
+	mgs := MockGitHubService{err: &providers.HttpStatusError{StatusCode: http.StatusServiceUnavailable}}
+	handler := GitHubProjectsHandler(
+		&mgs,
+		MochCacheProvider("", errors.New("not in cache"), nil),
+		nil,
+		5,
+		5,
+		[]byte("secret"),
+		version.GITHUB_API_2022_11_28,
+	)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io", nil)
+	w := NewMockResponseWriter()
+
+	err := handler(w, r, nil)
+
+	if err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	if w.StatusCode != http.StatusBadGateway {
+		t.Fatalf("Should have responded with status StatusBadGateway")
+	}
+}
+
+func TestGitHubRateLimitHandler_Valid(t *testing.T) {
+	mgs := MockGitHubService{}
+	handler := GitHubRateLimitHandler(&mgs, version.GITHUB_API_2022_11_28)
+
+	r, _ := http.NewRequest(http.MethodGet, "http://endpoint.io/rate_limit", nil)
+	w := NewMockResponseWriter()
+
+	err := handler(w, r, nil)
+
+	if err != nil {
+		t.Fatalf("api handler should not return an error")
+	}
+
+	result, _ := mgs.GetGithubRateLimit(r.Context(), nil)
+	expected, _ := json.Marshal(result)
+
+	if w.StatusCode != http.StatusOK {
+		t.Fatalf("Should have responded with status 200")
+	}
+
+	if !reflect.DeepEqual(w.Buffer.Bytes()[:len(w.Buffer.Bytes())-1], expected) {
+		t.Fatalf("Expected should have been written in response writter")
+	}
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
@@ -16,6 +17,10 @@ import (
 
 type MockGitHubService struct {
 	err error
+	// Total count of the matching repositories, defaults to 1
+	total int
+	// Failures of the fields of the matching repository
+	repositoryErrors []model.RepositoryError
 }
 
 func (mgs MockGitHubService) GetGithubProjectsWithStats(ctx context.Context, grb builder.GithubRequestBuilder) (repositories.GithubRepositoriesResult, error) {
@@ -23,36 +28,92 @@ func (mgs MockGitHubService) GetGithubProjectsWithStats(ctx context.Context, grb
 		return repositories.GithubRepositoriesResult{}, mgs.err
 	}
 
+	languages := model.NewLanguages(map[string]int{"JavaScript": 1548, "SCSS": 250})
+	for _, repositoryErr := range mgs.repositoryErrors {
+		if repositoryErr.Enrichment == "languages" {
+			languages = nil
+		}
+	}
+
 	return repositories.GithubRepositoriesResult{
-		Total:            1,
-		IncompleteResult: false,
+		Total:            max(mgs.total, 1),
+		IncompleteResult: len(mgs.repositoryErrors) != 0,
+		Errors:           mgs.repositoryErrors,
 		Repositories: []*model.Repository{
 			{
 				FullName:    "fmuiin14/BlazingTool",
 				Owner:       "fmuiin14",
 				Description: "Brute force ethereum wallet mnemonics",
 				Repository:  "fmuiin14/BlazingTool",
-				License: util.NullableJsonField[string]{
-					Value:  "mit",
+				License: util.NullableJsonField[model.License]{
+					Value:  model.License{Key: "mit", SpdxId: "MIT", Name: "MIT License"},
 					IsNull: false,
 				},
 				RepositoryUrl: "https://github.com/fmuiin14/BlazingTool",
-				CreatedAt:     "2024-10-19T10:17:16Z",
-				UpdatedAt:     "2024-10-20T16:36:13Z",
-				Languages: model.Language{
-					"JavaScript": model.LanguageStats{
-						Bytes: 1548,
-					},
-					"SCSS": model.LanguageStats{
-						Bytes: 250,
-					},
-				},
-				Size: 156464,
+				CreatedAt:     time.Date(2024, time.October, 19, 10, 17, 16, 0, time.UTC),
+				UpdatedAt:     time.Date(2024, time.October, 20, 16, 36, 13, 0, time.UTC),
+				Languages:     languages,
+				Size:          156464,
 			},
 		},
 	}, nil
 }
 
+func (mgs MockGitHubService) GetGithubProjectsAfter(ctx context.Context, grb builder.GithubRequestBuilder, cursor string) (repositories.GithubRepositoriesResult, error) {
+	result, err := mgs.GetGithubProjectsWithStats(ctx, grb)
+
+	// Second page of the walk unless it is started
+	result.CursorPage, result.CursorLimit = 2, 30
+	if cursor == "" {
+		limit, _ := grb.Paging()
+		result.CursorPage, result.CursorLimit = 1, limit
+		result.NextCursor = "next"
+	}
+
+	return result, err
+}
+
+func (mgs MockGitHubService) GetGithubProject(ctx context.Context, grb builder.GithubRequestBuilder, owner, name string) (*model.Repository, error) {
+	if mgs.err != nil {
+		return nil, mgs.err
+	}
+
+	result, _ := mgs.GetGithubProjectsWithStats(ctx, grb)
+
+	failures := make([]error, 0, len(mgs.repositoryErrors))
+	for _, repositoryErr := range mgs.repositoryErrors {
+		failures = append(failures, &repositories.EnrichmentError{Enrichment: repositoryErr.Enrichment, Err: errors.New(repositoryErr.Reason)})
+	}
+
+	return result.Repositories[0], errors.Join(failures...)
+}
+
+func (mgs MockGitHubService) GetGithubLanguagesStats(ctx context.Context, grb builder.GithubRequestBuilder) (model.LanguagesStats, error) {
+	if mgs.err != nil {
+		return model.LanguagesStats{}, mgs.err
+	}
+
+	return model.LanguagesStats{
+		RepositoryCount: 1,
+		TotalBytes:      1798,
+		Languages: map[string]model.AggregatedLanguageStats{
+			"JavaScript": {TotalBytes: 1548, RepositoryCount: 1, Share: 1548.0 / 1798.0, MeanBytes: 1548, MedianBytes: 1548},
+			"SCSS":       {TotalBytes: 250, RepositoryCount: 1, Share: 250.0 / 1798.0, MeanBytes: 250, MedianBytes: 250},
+		},
+	}, nil
+}
+
+func (mgs MockGitHubService) GetGithubRateLimit(ctx context.Context, grb builder.GithubRequestBuilder) (model.RateLimit, error) {
+	if mgs.err != nil {
+		return model.RateLimit{}, mgs.err
+	}
+
+	return model.RateLimit{
+		Core:   model.RateLimitResource{Limit: 5000, Remaining: 4999, Used: 1, Reset: time.Unix(1729500000, 0).UTC()},
+		Search: model.RateLimitResource{Limit: 30, Remaining: 30, Used: 0, Reset: time.Unix(1729500000, 0).UTC()},
+	}, nil
+}
+
 func MochCacheProvider(value string, getErr, setErr error) providers.CacheProvider {
 	return providers.NewRedisCacheProvider(&providers.RedisClient{
 		Get: func(ctx context.Context, s string) *redis.StringCmd {
@@ -79,6 +140,7 @@ func MochCacheProvider(value string, getErr, setErr error) providers.CacheProvid
 type MockResponseWriter struct {
 	StatusCode int
 	Buffer     *bytes.Buffer
+	Headers    http.Header
 }
 
 func (mrw *MockResponseWriter) Write(b []byte) (int, error) {
@@ -90,11 +152,12 @@ func (mrw *MockResponseWriter) WriteHeader(statusCode int) {
 }
 
 func (mrw *MockResponseWriter) Header() http.Header {
-	return http.Header{}
+	return mrw.Headers
 }
 
 func NewMockResponseWriter() *MockResponseWriter {
 	return &MockResponseWriter{
-		Buffer: &bytes.Buffer{},
+		Buffer:  &bytes.Buffer{},
+		Headers: http.Header{},
 	}
 }
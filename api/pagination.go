@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/builder"
+	"github.com/LasramR/sclng-backend-test-lasramR/repositories"
+	"github.com/LasramR/sclng-backend-test-lasramR/util"
+)
+
+// Pagination state of a list response derived from the total count and the effective limit.
+// Links are empty when the page they point to does not exist
+type pagination struct {
+	page       int
+	totalPages int
+	first      string
+	previous   string
+	next       string
+	last       string
+}
+
+// Computes the pagination of a page based request, Github search only serves its first GITHUB_SEARCH_RESULTS_CAP results
+func pagePagination(r *http.Request, grb builder.GithubRequestBuilder, total int) pagination {
+	limit, page := grb.Paging()
+	reachable := min(total, builder.GITHUB_SEARCH_RESULTS_CAP)
+	// An empty result is still made of a single empty page
+	totalPages := max((reachable+limit-1)/limit, 1)
+
+	p := pagination{
+		page:       page,
+		totalPages: totalPages,
+		first:      util.FullUrlFromRequestWith(r, "page", "1"),
+		last:       util.FullUrlFromRequestWith(r, "page", strconv.Itoa(totalPages)),
+	}
+
+	// A page past the end points back to the last page
+	if page > 1 {
+		p.previous = util.FullUrlFromRequestWith(r, "page", strconv.Itoa(min(page-1, totalPages)))
+	}
+
+	if page < totalPages {
+		p.next = util.FullUrlFromRequestWith(r, "page", strconv.Itoa(page+1))
+	}
+
+	return p
+}
+
+// Computes the pagination of a deep pagination walk, cursors of the links are signed with cursorSecret.
+// Walks only go forward: previous and last pages are never linked
+func cursorPagination(r *http.Request, repos repositories.GithubRepositoriesResult, cursorSecret []byte) pagination {
+	limit := max(repos.CursorLimit, 1)
+
+	p := pagination{
+		page:       repos.CursorPage,
+		totalPages: max((repos.Total+limit-1)/limit, 1),
+		first:      cursorPageUrl(r, "", limit),
+	}
+
+	if repos.NextCursor != "" {
+		p.next = cursorPageUrl(r, util.Sign(cursorSecret, repos.NextCursor), 0)
+	}
+
+	return p
+}
+
+// Returns the url of the request walked from cursor, the limit is carried by non empty cursors and only set for the first page
+func cursorPageUrl(r *http.Request, cursor string, limit int) string {
+	queryParams := r.URL.Query()
+	queryParams.Set("cursor", cursor)
+	queryParams.Del("limit")
+
+	if limit != 0 {
+		queryParams.Set("limit", strconv.Itoa(limit))
+	}
+
+	return util.FullUrlFromQuery(r, queryParams)
+}
+
+func (p pagination) hasPrevious() bool {
+	return p.previous != ""
+}
+
+func (p pagination) hasNext() bool {
+	return p.next != ""
+}
+
+// Formats the links of the pagination as a RFC 8288 Link header value
+func (p pagination) linkHeader() string {
+	links := make([]string, 0, 4)
+
+	for _, link := range [][2]string{{"first", p.first}, {"prev", p.previous}, {"next", p.next}, {"last", p.last}} {
+		if link[1] != "" {
+			links = append(links, fmt.Sprintf("<%s>; rel=\"%s\"", link[1], link[0]))
+		}
+	}
+
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/builder"
+	"github.com/LasramR/sclng-backend-test-lasramR/model/version"
+	"github.com/LasramR/sclng-backend-test-lasramR/util"
+)
+
+// Creates a GithubRequestBuilder configured from the query parameters of a request.
+// On failure, returns the HTTP status and the reasons that should be responded to the user
+func githubRequestBuilderFromQuery(apiVersion version.GithubAPIVersion, queryParams url.Values) (builder.GithubRequestBuilder, int, []string) {
+	grb, err := builder.NewGithubRequestBuilder(apiVersion)
+
+	if err != nil {
+		return nil, http.StatusServiceUnavailable, []string{err.Error()}
+	}
+
+	// Non empty cursors carry the limit of the walk they belong to
+	if queryParams.Get("cursor") != "" && queryParams.Has("limit") {
+		return nil, http.StatusBadRequest, []string{errors.New("cursor and limit parameters can't be combined").Error()}
+	}
+
+	// Presentation parameters are handled by the handler
+	queryParams.Del("format")
+	queryParams.Del("min_share")
+
+	// Setting results limit if set in query
+	limit := queryParams.Get("limit")
+	if limit != "" {
+		queryParams.Del("limit")
+		parsedLimit, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, http.StatusBadRequest, []string{errors.New("invalid limit parameter").Error()}
+		}
+
+		if err := grb.Limit(parsedLimit); err != nil {
+			return nil, http.StatusBadRequest, []string{err.Error()}
+		}
+	}
+
+	// Cursor pagination replaces the page parameter and is handled by the service layer
+	hasCursor := queryParams.Has("cursor")
+	if hasCursor {
+		if queryParams.Has("page") {
+			return nil, http.StatusBadRequest, []string{errors.New("cursor and page parameters can't be combined").Error()}
+		}
+		queryParams.Del("cursor")
+	}
+
+	// Setting results page if set in query
+	page := queryParams.Get("page")
+	if page != "" {
+		queryParams.Del("page")
+		parsedPage, err := strconv.Atoi(page)
+		if err != nil {
+			return nil, http.StatusBadRequest, []string{errors.New("invalid page parameter").Error()}
+		}
+
+		if err := grb.Page(parsedPage); err != nil {
+			return nil, http.StatusBadRequest, []string{err.Error()}
+		}
+	}
+
+	// Setting Github query sorting order if set in query
+	sort := queryParams.Get("sort")
+	if sort != "" {
+		queryParams.Del("sort")
+		if err := grb.Sort(sort); err != nil {
+			return nil, http.StatusBadRequest, []string{err.Error()}
+		}
+	}
+
+	// Setting Github query sorting direction if set in query
+	order := queryParams.Get("order")
+	if order != "" {
+		queryParams.Del("order")
+		if err := grb.Order(order); err != nil {
+			return nil, http.StatusBadRequest, []string{err.Error()}
+		}
+	}
+
+	// Restricting the fields of the returned repositories if set in query
+	if queryParams.Has("fields") {
+		fields := strings.Join(queryParams["fields"], ",")
+		queryParams.Del("fields")
+		if err := grb.Fields(fields); err != nil {
+			return nil, http.StatusBadRequest, []string{err.Error()}
+		}
+	}
+
+	// Opting into enrichments if set in query
+	if queryParams.Has("expand") {
+		expand := strings.Join(queryParams["expand"], ",")
+		queryParams.Del("expand")
+		if err := grb.Expand(expand); err != nil {
+			return nil, http.StatusBadRequest, []string{err.Error()}
+		}
+	}
+
+	// Consumming leftovers query parameters
+	queryParamsErrors := make([]string, 0, len(queryParams))
+	for _, k := range util.SortedKeys(queryParams) {
+		for _, v := range queryParams[k] {
+			if err := grb.With(k, v); err != nil {
+				queryParamsErrors = append(queryParamsErrors, err.Error())
+			}
+		}
+	}
+
+	// If we collected errors
+	if len(queryParamsErrors) != 0 {
+		return nil, http.StatusBadRequest, queryParamsErrors
+	}
+
+	// Created windows of a cursor walk are computed for a single Github query
+	if hasCursor && len(grb.Alternatives()) != 1 {
+		return nil, http.StatusBadRequest, []string{errors.New("cursor parameter can't be combined with alternative values").Error()}
+	}
+
+	return grb, http.StatusOK, nil
+}
+
+// Parses the min_share query parameter, the share in percents under which languages of the returned repositories are folded. 0 if unset
+func minShareFromQuery(queryParams url.Values) (float64, error) {
+	minShare := queryParams.Get("min_share")
+	if minShare == "" {
+		return 0, nil
+	}
+
+	parsedMinShare, err := strconv.ParseFloat(minShare, 64)
+	if err != nil || parsedMinShare < 0 || parsedMinShare > 100 {
+		return 0, errors.New("invalid min_share parameter, must be a number between 0 and 100")
+	}
+
+	return parsedMinShare, nil
+}
@@ -6,7 +6,10 @@ import (
 	"fmt"
 	"net/http"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/LasramR/sclng-backend-test-lasramR/model/version"
 	"github.com/LasramR/sclng-backend-test-lasramR/util"
@@ -18,24 +21,64 @@ type GithubRequestBuilder interface {
 	Build(ctx context.Context, method, baseUrl string) (*http.Request, error)
 	// Attach an authorization header
 	Authorization(value string)
-	// Adds a query parameter, error != nil if parameter "key" is not supported
+	// Adds a query parameter, error != nil if parameter "key" is not supported or "value" is invalid.
+	// Repeated values of a parameter such as language are alternatives (OR) and values prefixed by ! are negated (NOT).
+	// Free text terms (q or text) are quoted and restricted to some fields with the in parameter.
+	// Range qualifiers such as stars accept comparisons (>N, >=N, <N, <=N) and ranges (N..M) or a comparison suffix eg stars_gte
 	With(key, value string) error
+	// Splits the request into one GithubRequestBuilder per combination of alternative values, as a Github query cannot express them
+	Alternatives() []GithubRequestBuilder
 	// Adds a sort parameter, error != nil if sorting "value" is not supported
 	Sort(value string) error
+	// Sets the sort order (asc or desc), error != nil if order "value" is not supported
+	Order(value string) error
+	// Returns the effective sort and order of the request, best-match results are always in descending order
+	Sorting() (sort string, order string)
 	// Limits a request result count by "value", error != nil if "value" is invalid
 	Limit(value int) error
 	// Limits a request result count by "value", error != nil if "value" is invalid
 	Page(value int) error
+	// Returns the effective result count limit and page of the request
+	Paging() (limit int, page int)
+	// Returns a copy of the request restricted to repositories created between from and to (inclusive),
+	// error != nil if this window does not intersect the created parameter of the request
+	CreatedWindow(from, to time.Time) (GithubRequestBuilder, error)
+	// Restricts the returned repositories to the comma separated fields of "value", error != nil if a field is not supported
+	Fields(value string) error
+	// Opts into the comma separated enrichments of "value", error != nil if an enrichment is not supported
+	Expand(value string) error
+	// Returns the requested fields of the returned repositories, every default field if none was requested, followed by the expanded enrichments
+	Selection() []string
+	// Returns a copy of the request selecting the full_name field only, without languages nor enrichments to fetch
+	Minimal() GithubRequestBuilder
 }
 
 // Following types are used for function composition in order to abstract the request building process
 
-type githubParamSetter func(hrb *util.HttpRequestBuilder, params map[string]string)
-type githubSortSetter func(hrb *util.HttpRequestBuilder, sort string)
+type githubParamSetter func(hrb *util.HttpRequestBuilder, terms []string, params map[string][]string)
+type githubSortSetter func(hrb *util.HttpRequestBuilder, sort, order string)
 type authorizationSetter func(hrb *util.HttpRequestBuilder, authorization string)
 type limitSetter func(hrb *util.HttpRequestBuilder, limit int)
 type pageSetter func(hrb *util.HttpRequestBuilder, page int)
 
+// Github search only serves the first 1000 results of a query
+const GITHUB_SEARCH_RESULTS_CAP = 1000
+
+// Github search serves at most 100 results per page
+const GITHUB_SEARCH_MAX_LIMIT = 100
+
+// Default Github search sorting, ranking results by relevance
+const BEST_MATCH_SORT = "best-match"
+
+// Maximum number of Github queries a request with alternative values may be split into
+const maxAlternatives = 5
+
+// Github search limits : free text terms can't be longer than 256 characters and a query can't have more than 5 AND, OR, or NOT operators
+const (
+	maxSearchTextLength = 256
+	maxSearchOperators  = 5
+)
+
 type githubRequestBuilderAPIVersionned struct {
 	apiVersion              version.GithubAPIVersion
 	apiBaseUrl              string
@@ -43,18 +86,35 @@ type githubRequestBuilderAPIVersionned struct {
 	authorizationValue      string
 	supportedParams         map[string]string
 	paramSetterFunc         githubParamSetter
-	params                  map[string]string
+	params                  map[string][]string
+	supportedTextParams     []string
+	supportedInValues       []string
+	terms                   []string
+	supportedMultiParams    map[string]string
+	supportedStateParams    map[string][]string
+	supportedRangeParams    map[string]rangeQualifierKind
+	rangeParams             map[string]*rangeQualifier
 	supportedSort           []string
 	sortSetter              githubSortSetter
 	sortBy                  string
+	supportedOrder          []string
+	orderValue              string
 	limitSetterFunc         limitSetter
 	maxLimit                int
 	limitValue              int
 	pageSetterFunc          pageSetter
 	pageValue               int
+	supportedFields         []string
+	fields                  []string
+	supportedExpansions     []string
+	expansions              []string
 }
 
 func (grb *githubRequestBuilderAPIVersionned) Build(ctx context.Context, method, url string) (*http.Request, error) {
+	if grb.alternativesCount() > 1 {
+		return nil, errors.New("request with alternative values must be built from its Alternatives")
+	}
+
 	var fullUrl string
 	if strings.HasSuffix(grb.apiBaseUrl, "/") {
 		fullUrl = grb.apiBaseUrl + url
@@ -70,14 +130,20 @@ func (grb *githubRequestBuilderAPIVersionned) Build(ctx context.Context, method,
 		grb.authorizationSetterFunc(hrb, grb.authorizationValue)
 	}
 
-	if len(grb.params) != 0 {
-		grb.paramSetterFunc(hrb, grb.params)
+	params := make(map[string][]string, len(grb.params)+len(grb.rangeParams))
+	for k, v := range grb.params {
+		params[k] = v
+	}
+	for k, v := range grb.rangeParams {
+		params[k] = []string{v.String()}
 	}
 
-	if grb.sortBy != "" {
-		grb.sortSetter(hrb, grb.sortBy)
+	if len(params) != 0 || len(grb.terms) != 0 {
+		grb.paramSetterFunc(hrb, grb.terms, params)
 	}
 
+	grb.sortSetter(hrb, grb.sortBy, grb.orderValue)
+
 	grb.limitSetterFunc(hrb, grb.limitValue)
 	grb.pageSetterFunc(hrb, grb.pageValue)
 
@@ -90,13 +156,228 @@ func (grb *githubRequestBuilderAPIVersionned) Authorization(value string) {
 	}
 }
 func (grb *githubRequestBuilderAPIVersionned) With(key, value string) error {
-	if _, ok := grb.supportedParams[key]; ok && value != "" {
-		grb.params[key] = value
+	qualifier, isAlternative := grb.supportedParams[key]
+	multiQualifier, isMulti := grb.supportedMultiParams[key]
+
+	if (isAlternative || isMulti) && value != "" {
+		if isMulti {
+			qualifier = multiQualifier
+		}
+
+		// Negated values are excluded with AND semantics, eg -language:javascript
+		if negated, ok := strings.CutPrefix(value, "!"); ok {
+			if negated == "" {
+				return fmt.Errorf("%s parameter negated value must not be empty", key)
+			}
+
+			qualifier, value, isAlternative = "-"+qualifier, negated, false
+		}
+
+		if slices.Contains(grb.params[qualifier], value) {
+			return nil
+		}
+
+		grb.params[qualifier] = append(grb.params[qualifier], value)
+
+		if err := grb.validateSearchLimits(key); err != nil {
+			grb.params[qualifier] = grb.params[qualifier][:len(grb.params[qualifier])-1]
+			if len(grb.params[qualifier]) == 0 {
+				delete(grb.params, qualifier)
+			}
+			return err
+		}
+
+		if isAlternative && grb.alternativesCount() > maxAlternatives {
+			grb.params[qualifier] = grb.params[qualifier][:len(grb.params[qualifier])-1]
+			return fmt.Errorf("%s parameter values exceed the maximum of %d alternative queries", key, maxAlternatives)
+		}
+
+		return nil
+	}
+
+	if slices.Contains(grb.supportedTextParams, key) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return fmt.Errorf("%s parameter value must not be empty", key)
+		}
+
+		grb.terms = append(grb.terms, value)
+
+		if err := grb.validateSearchLimits(key); err != nil {
+			grb.terms = grb.terms[:len(grb.terms)-1]
+			return err
+		}
+
+		return nil
+	}
+
+	// Restricts the fields searched by free text terms, eg in=name,description
+	if key == "in" {
+		fields := make([]string, 0)
+		for _, field := range strings.Split(value, ",") {
+			field = strings.ToLower(strings.TrimSpace(field))
+
+			if !slices.Contains(grb.supportedInValues, field) {
+				return fmt.Errorf("in parameter value %q is not supported [%s] allowed", field, strings.Join(grb.supportedInValues, ","))
+			}
+
+			if !slices.Contains(fields, field) {
+				fields = append(fields, field)
+			}
+		}
+
+		// Sorted so that the request url is deterministic for caching purposes
+		slices.Sort(fields)
+		grb.params["in"] = []string{strings.Join(fields, ",")}
+		return nil
+	}
+
+	if allowed, ok := grb.supportedStateParams[key]; ok {
+		value = strings.ToLower(value)
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			value = strconv.FormatBool(parsed)
+		}
+
+		if !slices.Contains(allowed, value) {
+			return fmt.Errorf("%s parameter value %q is not supported [%s] allowed", key, value, strings.Join(allowed, ","))
+		}
+
+		// Forks are excluded from Github search results by default
+		if key == "fork" && value == "false" {
+			delete(grb.params, key)
+			return nil
+		}
+
+		grb.params[key] = []string{value}
+		return nil
+	}
+
+	qualifier, operator := key, ""
+	for suffix, suffixOperator := range rangeQualifierSuffixes {
+		if trimmed, ok := strings.CutSuffix(key, suffix); ok {
+			qualifier, operator = trimmed, suffixOperator
+			break
+		}
+	}
+
+	if kind, ok := grb.supportedRangeParams[qualifier]; ok {
+		rq, ok := grb.rangeParams[qualifier]
+		if !ok {
+			rq = &rangeQualifier{kind: kind}
+		}
+
+		if err := rq.add(key, operator, value); err != nil {
+			return err
+		}
+
+		grb.rangeParams[qualifier] = rq
 		return nil
 	}
 
 	return fmt.Errorf("%s parameter is not supported", key)
 }
+func (grb *githubRequestBuilderAPIVersionned) Alternatives() []GithubRequestBuilder {
+	alternatives := []*githubRequestBuilderAPIVersionned{grb.clone()}
+
+	for _, qualifier := range util.SortedKeys(grb.params) {
+		values := grb.params[qualifier]
+		if !grb.isAlternativeQualifier(qualifier) || len(values) < 2 {
+			continue
+		}
+
+		// Sorted so that alternatives are deterministic for caching purposes
+		next := make([]*githubRequestBuilderAPIVersionned, 0, len(alternatives)*len(values))
+		for _, alternative := range alternatives {
+			for _, value := range slices.Sorted(slices.Values(values)) {
+				split := alternative.clone()
+				split.params[qualifier] = []string{value}
+				next = append(next, split)
+			}
+		}
+		alternatives = next
+	}
+
+	result := make([]GithubRequestBuilder, 0, len(alternatives))
+	for _, alternative := range alternatives {
+		result = append(result, alternative)
+	}
+
+	return result
+}
+
+// Validates the Github search limits before any request is sent, key is the parameter being added
+func (grb *githubRequestBuilderAPIVersionned) validateSearchLimits(key string) error {
+	textLength := 0
+	for i, term := range grb.terms {
+		if i != 0 {
+			textLength++
+		}
+		textLength += utf8.RuneCountInString(term)
+	}
+
+	if textLength > maxSearchTextLength {
+		return fmt.Errorf("%s parameter exceeds the maximum search text length of %d characters", key, maxSearchTextLength)
+	}
+
+	// Each negated value is a NOT operator
+	operators := 0
+	for qualifier, values := range grb.params {
+		if strings.HasPrefix(qualifier, "-") {
+			operators += len(values)
+		}
+	}
+
+	if operators > maxSearchOperators {
+		return fmt.Errorf("%s parameter exceeds the maximum of %d boolean operators", key, maxSearchOperators)
+	}
+
+	return nil
+}
+
+// Number of Github queries needed to fetch every combination of alternative values
+func (grb *githubRequestBuilderAPIVersionned) alternativesCount() int {
+	count := 1
+	for qualifier, values := range grb.params {
+		if grb.isAlternativeQualifier(qualifier) {
+			count *= max(len(values), 1)
+		}
+	}
+
+	return count
+}
+
+// Describes if repeated values of qualifier are alternatives
+func (grb *githubRequestBuilderAPIVersionned) isAlternativeQualifier(qualifier string) bool {
+	for _, supported := range grb.supportedParams {
+		if supported == qualifier {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Deep copies the builder so that a copy can be modified independently
+func (grb *githubRequestBuilderAPIVersionned) clone() *githubRequestBuilderAPIVersionned {
+	clone := *grb
+	clone.terms = slices.Clone(grb.terms)
+	clone.fields = slices.Clone(grb.fields)
+	clone.expansions = slices.Clone(grb.expansions)
+
+	clone.params = make(map[string][]string, len(grb.params))
+	for k, v := range grb.params {
+		clone.params[k] = slices.Clone(v)
+	}
+
+	clone.rangeParams = make(map[string]*rangeQualifier, len(grb.rangeParams))
+	for k, v := range grb.rangeParams {
+		rq := *v
+		clone.rangeParams[k] = &rq
+	}
+
+	return &clone
+}
+
 func (grb *githubRequestBuilderAPIVersionned) Sort(value string) error {
 	if slices.Contains(grb.supportedSort, value) {
 		grb.sortBy = value
@@ -106,6 +387,23 @@ func (grb *githubRequestBuilderAPIVersionned) Sort(value string) error {
 	return fmt.Errorf("%s sorting is not supported [%s] allowed", value, strings.Join(grb.supportedSort, ","))
 }
 
+func (grb *githubRequestBuilderAPIVersionned) Order(value string) error {
+	if slices.Contains(grb.supportedOrder, value) {
+		grb.orderValue = value
+		return nil
+	}
+
+	return fmt.Errorf("%s order is not supported [%s] allowed", value, strings.Join(grb.supportedOrder, ","))
+}
+
+func (grb *githubRequestBuilderAPIVersionned) Sorting() (string, string) {
+	if grb.sortBy == BEST_MATCH_SORT {
+		return grb.sortBy, "desc"
+	}
+
+	return grb.sortBy, grb.orderValue
+}
+
 func (grb *githubRequestBuilderAPIVersionned) Limit(value int) error {
 	if value < 1 || grb.maxLimit < value {
 		return fmt.Errorf("parameter limit %d is exceeding max limit of %d", value, grb.maxLimit)
@@ -124,14 +422,97 @@ func (grb *githubRequestBuilderAPIVersionned) Page(value int) error {
 	return nil
 }
 
+func (grb *githubRequestBuilderAPIVersionned) Paging() (int, int) {
+	return grb.limitValue, grb.pageValue
+}
+
+func (grb *githubRequestBuilderAPIVersionned) CreatedWindow(from, to time.Time) (GithubRequestBuilder, error) {
+	kind, ok := grb.supportedRangeParams["created"]
+	if !ok {
+		return nil, errors.New("created parameter is not supported")
+	}
+
+	window := grb.clone()
+	created, ok := window.rangeParams["created"]
+	if !ok {
+		created = &rangeQualifier{kind: kind}
+	}
+
+	restricted, ok := created.restrict(dateRangeValue(from), dateRangeValue(to))
+	if !ok {
+		return nil, fmt.Errorf("created window %s..%s does not intersect the created parameter", dateRangeValue(from).raw, dateRangeValue(to).raw)
+	}
+
+	window.rangeParams["created"] = &restricted
+	return window, nil
+}
+
+func (grb *githubRequestBuilderAPIVersionned) Fields(value string) error {
+	fields, err := parseSelection("field", value, grb.supportedFields)
+	if err != nil {
+		return err
+	}
+
+	grb.fields = fields
+	return nil
+}
+
+func (grb *githubRequestBuilderAPIVersionned) Expand(value string) error {
+	expansions, err := parseSelection("expand value", value, grb.supportedExpansions)
+	if err != nil {
+		return err
+	}
+
+	grb.expansions = expansions
+	return nil
+}
+
+func (grb *githubRequestBuilderAPIVersionned) Selection() []string {
+	fields := grb.fields
+	if fields == nil {
+		fields = grb.supportedFields
+	}
+
+	return append(slices.Clone(fields), grb.expansions...)
+}
+
+func (grb *githubRequestBuilderAPIVersionned) Minimal() GithubRequestBuilder {
+	minimal := grb.clone()
+	minimal.fields = []string{"full_name"}
+	minimal.expansions = nil
+
+	return minimal
+}
+
+// Parses a comma separated list of names, each of them must be supported. Returned names are sorted and deduplicated
+func parseSelection(kind, value string, supported []string) ([]string, error) {
+	names := make([]string, 0)
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+
+		if !slices.Contains(supported, name) {
+			return nil, fmt.Errorf("%s %q is not supported [%s] allowed", kind, name, strings.Join(supported, ","))
+		}
+
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return slices.Compact(names), nil
+}
+
 // Factory method that creates a GithubRequestBuilder for a specific API version, err != nil if API version is not supported
 func NewGithubRequestBuilder(ApiVersion version.GithubAPIVersion) (GithubRequestBuilder, error) {
 	switch ApiVersion {
 	case version.GITHUB_API_2022_11_28:
 		return &githubRequestBuilderAPIVersionned{
-			apiVersion:    version.GITHUB_API_2022_11_28,
-			apiBaseUrl:    "https://api.github.com",
-			supportedSort: []string{"updated", "forks", "stars"},
+			apiVersion:     version.GITHUB_API_2022_11_28,
+			apiBaseUrl:     "https://api.github.com",
+			supportedSort:  []string{BEST_MATCH_SORT, "updated", "forks", "stars", "help-wanted-issues"},
+			sortBy:         BEST_MATCH_SORT,
+			supportedOrder: []string{"asc", "desc"},
+			orderValue:     "desc",
 			authorizationSetterFunc: func(hrb *util.HttpRequestBuilder, authorization string) {
 				hrb.AddHeader("Authorization", []string{fmt.Sprintf("Bearer %s", authorization)})
 			},
@@ -142,19 +523,49 @@ func NewGithubRequestBuilder(ApiVersion version.GithubAPIVersion) (GithubRequest
 				"org":       "org",
 				"full_name": "repo",
 			},
-			params: map[string]string{"is": "public"},
-			paramSetterFunc: func(hrb *util.HttpRequestBuilder, params map[string]string) {
-				stringifiedParams := make([]string, 0, len(params))
+			params:              map[string][]string{"is": {"public"}},
+			supportedTextParams: []string{"q", "text"},
+			supportedInValues:   []string{"name", "description", "readme", "topics"},
+			supportedMultiParams: map[string]string{
+				"topic": "topic",
+			},
+			supportedStateParams: map[string][]string{
+				"archived": {"true", "false"},
+				"fork":     {"true", "false", "only"},
+				"template": {"true", "false"},
+				"mirror":   {"true", "false"},
+			},
+			supportedRangeParams: map[string]rangeQualifierKind{
+				"stars":     integerRangeQualifier,
+				"forks":     integerRangeQualifier,
+				"size":      integerRangeQualifier,
+				"topics":    integerRangeQualifier,
+				"followers": integerRangeQualifier,
+				"created":   dateRangeQualifier,
+				"pushed":    dateRangeQualifier,
+			},
+			rangeParams: make(map[string]*rangeQualifier),
+			paramSetterFunc: func(hrb *util.HttpRequestBuilder, terms []string, params map[string][]string) {
+				stringifiedParams := make([]string, 0, len(terms)+len(params))
+				for _, term := range terms {
+					stringifiedParams = append(stringifiedParams, fmt.Sprintf("\"%s\"", strings.ReplaceAll(term, "\"", "\\\"")))
+				}
 				for _, k := range util.SortedKeys(params) { // Ensure that request url is deterministic for caching purposes
-					v := params[k]
-					stringifiedParams = append(stringifiedParams, fmt.Sprintf("%s:%s", k, v))
+					for _, v := range slices.Sorted(slices.Values(params[k])) {
+						stringifiedParams = append(stringifiedParams, fmt.Sprintf("%s:%s", k, v))
+					}
 				}
 				hrb.AddQueryParam("q", strings.Join(stringifiedParams, " "))
 			},
-			sortSetter: func(hrb *util.HttpRequestBuilder, sort string) {
+			sortSetter: func(hrb *util.HttpRequestBuilder, sort, order string) {
+				// Github sorts by best match when no sort is given, order is ignored in that case
+				if sort == BEST_MATCH_SORT {
+					return
+				}
 				hrb.AddQueryParam("sort", sort)
+				hrb.AddQueryParam("order", order)
 			},
-			maxLimit: 100,
+			maxLimit: GITHUB_SEARCH_MAX_LIMIT,
 			limitSetterFunc: func(hrb *util.HttpRequestBuilder, limit int) {
 				hrb.AddQueryParam("per_page", fmt.Sprintf("%d", limit))
 			},
@@ -163,6 +574,13 @@ func NewGithubRequestBuilder(ApiVersion version.GithubAPIVersion) (GithubRequest
 				hrb.AddQueryParam("page", fmt.Sprintf("%d", page))
 			},
 			pageValue: 1,
+			// Json fields of model.Repository
+			supportedFields: []string{
+				"full_name", "owner", "description", "repository", "repository_url", "homepage", "languages", "primary_language", "license", "topics", "default_branch", "visibility",
+				"archived", "fork", "stars", "forks", "open_issues", "watchers", "size", "created_at", "updated_at", "pushed_at",
+			},
+			// Enrichments require extra Github requests per repository, they are fetched on demand only
+			supportedExpansions: []string{"activity", "contributors", "releases"},
 		}, nil
 	default:
 		return nil, errors.New("unsupported github api version")
@@ -4,6 +4,8 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
@@ -62,3 +64,208 @@ func TestGithubRequestBuilder_20221128(t *testing.T) {
 		t.Fatalf("GithubRequestBuilder %s missing page=1 from built request URL", version.GITHUB_API_2022_11_28)
 	}
 }
+
+func TestGithubRequestBuilder_StateFilters(t *testing.T) {
+	grb, _ := NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	_ = grb.With("topic", "kubernetes")
+	_ = grb.With("topic", "helm")
+	_ = grb.With("archived", "False")
+	_ = grb.With("fork", "only")
+	_ = grb.With("template", "0")
+	_ = grb.With("mirror", "false")
+
+	req, _ := grb.Build(context.Background(), http.MethodGet, "/search/repositories")
+
+	expected := "archived:false fork:only is:public mirror:false template:false topic:helm topic:kubernetes"
+	if req.URL.Query().Get("q") != expected {
+		t.Fatalf("GithubRequestBuilder %s should have built q=%s, got %s", version.GITHUB_API_2022_11_28, expected, req.URL.Query().Get("q"))
+	}
+}
+
+func TestGithubRequestBuilder_InvalidStateFilters(t *testing.T) {
+	grb, _ := NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	if err := grb.With("archived", "maybe"); err == nil || err.Error() != `archived parameter value "maybe" is not supported [true,false] allowed` {
+		t.Fatalf("archived parameter should only accept booleans")
+	}
+
+	if err := grb.With("fork", "yes please"); err == nil {
+		t.Fatalf("fork parameter should only accept booleans and only")
+	}
+
+	if err := grb.With("topic", "!"); err == nil {
+		t.Fatalf("topic parameter should not accept an empty negated value")
+	}
+}
+
+func TestGithubRequestBuilder_Negated(t *testing.T) {
+	grb, _ := NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	_ = grb.With("language", "!javascript")
+	_ = grb.With("language", "!typescript")
+	_ = grb.With("topic", "!helm")
+
+	req, err := grb.Build(context.Background(), http.MethodGet, "/search/repositories")
+
+	expected := "-language:javascript -language:typescript -topic:helm is:public"
+	if err != nil || req.URL.Query().Get("q") != expected {
+		t.Fatalf("GithubRequestBuilder %s should have built q=%s", version.GITHUB_API_2022_11_28, expected)
+	}
+}
+
+func TestGithubRequestBuilder_Alternatives(t *testing.T) {
+	grb, _ := NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	_ = grb.With("language", "rust")
+	_ = grb.With("language", "go")
+	_ = grb.With("user", "a")
+	_ = grb.With("user", "b")
+	_ = grb.With("stars_gte", "10")
+
+	if _, err := grb.Build(context.Background(), http.MethodGet, "/search/repositories"); err == nil {
+		t.Fatalf("Request with alternative values should not be built as a single query")
+	}
+
+	expected := []string{
+		"is:public language:go stars:>=10 user:a",
+		"is:public language:go stars:>=10 user:b",
+		"is:public language:rust stars:>=10 user:a",
+		"is:public language:rust stars:>=10 user:b",
+	}
+	alternatives := grb.Alternatives()
+
+	if len(alternatives) != len(expected) {
+		t.Fatalf("Should have been split into %d alternatives, got %d", len(expected), len(alternatives))
+	}
+
+	for i, alternative := range alternatives {
+		req, err := alternative.Build(context.Background(), http.MethodGet, "/search/repositories")
+
+		if err != nil || req.URL.Query().Get("q") != expected[i] {
+			t.Fatalf("Alternative %d should have built q=%s", i, expected[i])
+		}
+	}
+
+	if err := grb.With("org", "c"); err != nil {
+		t.Fatalf("Single org value should not add alternatives")
+	}
+
+	if err := grb.With("org", "d"); err == nil {
+		t.Fatalf("Alternatives should be limited to %d queries", maxAlternatives)
+	}
+}
+
+func TestGithubRequestBuilder_FreeText(t *testing.T) {
+	grb, _ := NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	_ = grb.With("q", `kubernetes "operator"`)
+	_ = grb.With("text", "helm")
+	_ = grb.With("in", "readme,Name,name")
+
+	req, err := grb.Build(context.Background(), http.MethodGet, "/search/repositories")
+
+	expected := `"kubernetes \"operator\"" "helm" in:name,readme is:public`
+	if err != nil || req.URL.Query().Get("q") != expected {
+		t.Fatalf("GithubRequestBuilder %s should have built q=%s, got %s", version.GITHUB_API_2022_11_28, expected, req.URL.Query().Get("q"))
+	}
+}
+
+func TestGithubRequestBuilder_SearchLimits(t *testing.T) {
+	grb, _ := NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	if err := grb.With("in", "name,license"); err == nil || err.Error() != `in parameter value "license" is not supported [name,description,readme,topics] allowed` {
+		t.Fatalf("in parameter should only accept searchable fields")
+	}
+
+	if err := grb.With("q", strings.Repeat("a", 257)); err == nil || err.Error() != "q parameter exceeds the maximum search text length of 256 characters" {
+		t.Fatalf("q parameter should not exceed the Github search text length")
+	}
+
+	for _, language := range []string{"a", "b", "c", "d", "e"} {
+		if err := grb.With("language", "!"+language); err != nil {
+			t.Fatalf("Up to 5 negated values should be supported")
+		}
+	}
+
+	if err := grb.With("topic", "!f"); err == nil || err.Error() != "topic parameter exceeds the maximum of 5 boolean operators" {
+		t.Fatalf("Query should not exceed the Github boolean operators limit")
+	}
+
+	req, _ := grb.Build(context.Background(), http.MethodGet, "/search/repositories")
+	if strings.Contains(req.URL.Query().Get("q"), "topic") {
+		t.Fatalf("Rejected values should not be added to the query")
+	}
+}
+
+func TestGithubRequestBuilder_Sorting(t *testing.T) {
+	grb, _ := NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	if sort, order := grb.Sorting(); sort != BEST_MATCH_SORT || order != "desc" {
+		t.Fatalf("Requests should be sorted by best match by default")
+	}
+
+	_ = grb.Order("asc")
+	req, _ := grb.Build(context.Background(), http.MethodGet, "/search/repositories")
+
+	if _, order := grb.Sorting(); order != "desc" || req.URL.Query().Has("sort") || req.URL.Query().Has("order") {
+		t.Fatalf("Best match sorting should ignore order")
+	}
+
+	_ = grb.Sort("help-wanted-issues")
+	req, _ = grb.Build(context.Background(), http.MethodGet, "/search/repositories")
+
+	if req.URL.Query().Get("sort") != "help-wanted-issues" || req.URL.Query().Get("order") != "asc" {
+		t.Fatalf("GithubRequestBuilder %s missing sort=help-wanted-issues&order=asc from built request URL", version.GITHUB_API_2022_11_28)
+	}
+
+	if err := grb.Order("up"); err == nil || err.Error() != "up order is not supported [asc,desc] allowed" {
+		t.Fatalf("Order should only accept asc or desc")
+	}
+}
+
+func TestGithubRequestBuilder_Selection(t *testing.T) {
+	grb, _ := NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	if !slices.Contains(grb.Selection(), "languages") || !slices.Contains(grb.Selection(), "full_name") {
+		t.Fatalf("Every field should be selected by default")
+	}
+
+	if err := grb.Fields("owner, full_name,owner"); err != nil {
+		t.Fatalf("Supported fields should be accepted, got %s", err)
+	}
+
+	if !reflect.DeepEqual(grb.Selection(), []string{"full_name", "owner"}) {
+		t.Fatalf("Selection should be restricted to the sorted requested fields, got %v", grb.Selection())
+	}
+
+	if err := grb.Fields("full_name,stargazers"); err == nil || !strings.Contains(err.Error(), `field "stargazers" is not supported`) {
+		t.Fatalf("Unsupported fields should be rejected")
+	}
+
+	if err := grb.Expand("everything"); err == nil {
+		t.Fatalf("Unsupported enrichments should be rejected")
+	}
+}
+
+func TestGithubRequestBuilder_Minimal(t *testing.T) {
+	grb, _ := NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	_ = grb.Fields("full_name,languages")
+	_ = grb.Expand("contributors,releases")
+	_ = grb.With("language", "go")
+
+	minimal := grb.Minimal()
+
+	if !reflect.DeepEqual(minimal.Selection(), []string{"full_name"}) {
+		t.Fatalf("Minimal request should only select full_name, got %v", minimal.Selection())
+	}
+
+	if !reflect.DeepEqual(grb.Selection(), []string{"full_name", "languages", "contributors", "releases"}) {
+		t.Fatalf("Original request selection should be kept, got %v", grb.Selection())
+	}
+
+	req, _ := minimal.Build(context.Background(), http.MethodGet, "/search/repositories")
+	if req.URL.Query().Get("q") != "is:public language:go" {
+		t.Fatalf("Minimal request should keep the query of the original one, got %s", req.URL.Query().Get("q"))
+	}
+}
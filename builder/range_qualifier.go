@@ -0,0 +1,240 @@
+package builder
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind of the values accepted by a range qualifier
+type rangeQualifierKind int
+
+const (
+	integerRangeQualifier rangeQualifierKind = iota
+	dateRangeQualifier
+)
+
+// Comparison operators of range qualifiers parameters suffixes, eg stars_gte=1000
+var rangeQualifierSuffixes = map[string]string{
+	"_gt":  ">",
+	"_gte": ">=",
+	"_lt":  "<",
+	"_lte": "<=",
+}
+
+// Date layouts accepted by the Github search syntax
+var rangeQualifierDateLayouts = []string{time.DateOnly, "2006-01-02T15:04:05", time.RFC3339}
+
+// A validated value of a range qualifier
+type rangeValue struct {
+	raw    string
+	number int
+	date   time.Time
+	layout string
+}
+
+// A lower or upper bound of a range qualifier
+type rangeBound struct {
+	value     rangeValue
+	inclusive bool
+	set       bool
+}
+
+// Qualifier restricting a numeric or date field of the searched repositories, eg stars:>=1000 or created:2024-01-01..2024-06-30
+type rangeQualifier struct {
+	kind  rangeQualifierKind
+	lower rangeBound
+	upper rangeBound
+}
+
+// Parses a single value of a range qualifier, param is the query parameter name used in error messages
+func (k rangeQualifierKind) parse(param, value string) (rangeValue, error) {
+	switch k {
+	case dateRangeQualifier:
+		for _, layout := range rangeQualifierDateLayouts {
+			if date, err := time.Parse(layout, value); err == nil {
+				return rangeValue{raw: value, date: date, layout: layout}, nil
+			}
+		}
+
+		return rangeValue{}, fmt.Errorf("%s parameter value %q must be a date formatted as YYYY-MM-DD, YYYY-MM-DDTHH:MM:SS or YYYY-MM-DDTHH:MM:SS+07:00", param, value)
+	default:
+		number, err := strconv.Atoi(value)
+
+		if err != nil || number < 0 {
+			return rangeValue{}, fmt.Errorf("%s parameter value %q must be a non-negative integer", param, value)
+		}
+
+		return rangeValue{raw: value, number: number}, nil
+	}
+}
+
+// Compares two values of the same kind
+func (k rangeQualifierKind) compare(a, b rangeValue) int {
+	if k == dateRangeQualifier {
+		return a.date.Compare(b.date)
+	}
+
+	return cmp.Compare(a.number, b.number)
+}
+
+// Returns the closest value after (by = 1) or before (by = -1) value, used to turn exclusive bounds into inclusive ones
+func (k rangeQualifierKind) shift(value rangeValue, by int) rangeValue {
+	if k == dateRangeQualifier {
+		date := value.date.Add(time.Duration(by) * time.Second)
+		if value.layout == time.DateOnly {
+			date = value.date.AddDate(0, 0, by)
+		}
+
+		return rangeValue{raw: date.Format(value.layout), date: date, layout: value.layout}
+	}
+
+	return rangeValue{raw: strconv.Itoa(value.number + by), number: value.number + by}
+}
+
+// Adds the bounds described by value to the qualifier, operator is set when value was given through a suffixed parameter, eg stars_gte.
+// Accepted values are N, >N, >=N, <N, <=N and N..M where N or M may be * for an unbounded side
+func (rq *rangeQualifier) add(param, operator, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s parameter value must not be empty", param)
+	}
+
+	if operator == "" {
+		operator, value = splitRangeOperator(value)
+	}
+
+	var lower, upper rangeBound
+
+	if from, to, isRange := strings.Cut(value, ".."); isRange && operator == "" {
+		if from != "*" {
+			parsed, err := rq.kind.parse(param, from)
+			if err != nil {
+				return err
+			}
+			lower = rangeBound{value: parsed, inclusive: true, set: true}
+		}
+
+		if to != "*" {
+			parsed, err := rq.kind.parse(param, to)
+			if err != nil {
+				return err
+			}
+			upper = rangeBound{value: parsed, inclusive: true, set: true}
+		}
+	} else {
+		parsed, err := rq.kind.parse(param, value)
+		if err != nil {
+			return err
+		}
+
+		switch operator {
+		case ">", ">=":
+			lower = rangeBound{value: parsed, inclusive: operator == ">=", set: true}
+		case "<", "<=":
+			upper = rangeBound{value: parsed, inclusive: operator == "<=", set: true}
+		default:
+			lower = rangeBound{value: parsed, inclusive: true, set: true}
+			upper = lower
+		}
+	}
+
+	if lower.set && rq.lower.set {
+		return fmt.Errorf("%s parameter conflicts with a lower bound already set to %s", param, rq.lower.value.raw)
+	}
+
+	if upper.set && rq.upper.set {
+		return fmt.Errorf("%s parameter conflicts with an upper bound already set to %s", param, rq.upper.value.raw)
+	}
+
+	merged := *rq
+	if lower.set {
+		merged.lower = lower
+	}
+	if upper.set {
+		merged.upper = upper
+	}
+
+	if merged.isEmpty() {
+		return fmt.Errorf("%s parameter results in an empty range between %s and %s", param, merged.lower.value.raw, merged.upper.value.raw)
+	}
+
+	*rq = merged
+	return nil
+}
+
+// Returns the qualifier restricted to the values between lower and upper (inclusive), ok is false if the restricted range is empty
+func (rq *rangeQualifier) restrict(lower, upper rangeValue) (rangeQualifier, bool) {
+	restricted := *rq
+
+	if !restricted.lower.set || rq.kind.compare(lower, restricted.lower.value) > 0 {
+		restricted.lower = rangeBound{value: lower, inclusive: true, set: true}
+	}
+
+	if !restricted.upper.set || rq.kind.compare(upper, restricted.upper.value) < 0 {
+		restricted.upper = rangeBound{value: upper, inclusive: true, set: true}
+	}
+
+	return restricted, !restricted.isEmpty()
+}
+
+// Describes if no value can match the qualifier
+func (rq *rangeQualifier) isEmpty() bool {
+	if !rq.lower.set || !rq.upper.set {
+		return false
+	}
+
+	order := rq.kind.compare(rq.lower.value, rq.upper.value)
+	return order > 0 || (order == 0 && !(rq.lower.inclusive && rq.upper.inclusive))
+}
+
+// Creates a date range value from a time, formatted in UTC with a second precision
+func dateRangeValue(date time.Time) rangeValue {
+	date = date.UTC().Truncate(time.Second)
+	return rangeValue{raw: date.Format(time.RFC3339), date: date, layout: time.RFC3339}
+}
+
+// Formats the qualifier value using the Github search syntax
+func (rq *rangeQualifier) String() string {
+	switch {
+	case rq.lower.set && rq.upper.set:
+		// Github ranges are inclusive
+		lower, upper := rq.lower.value, rq.upper.value
+		if !rq.lower.inclusive {
+			lower = rq.kind.shift(lower, 1)
+		}
+		if !rq.upper.inclusive {
+			upper = rq.kind.shift(upper, -1)
+		}
+
+		if rq.kind.compare(lower, upper) == 0 {
+			return lower.raw
+		}
+
+		return fmt.Sprintf("%s..%s", lower.raw, upper.raw)
+	case rq.lower.set:
+		if rq.lower.inclusive {
+			return ">=" + rq.lower.value.raw
+		}
+		return ">" + rq.lower.value.raw
+	case rq.upper.set:
+		if rq.upper.inclusive {
+			return "<=" + rq.upper.value.raw
+		}
+		return "<" + rq.upper.value.raw
+	default:
+		return ""
+	}
+}
+
+// Splits a leading comparison operator from value
+func splitRangeOperator(value string) (string, string) {
+	for _, operator := range []string{">=", "<=", ">", "<"} {
+		if rest, ok := strings.CutPrefix(value, operator); ok {
+			return operator, rest
+		}
+	}
+
+	return "", value
+}
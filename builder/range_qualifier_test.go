@@ -0,0 +1,102 @@
+package builder
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/model/version"
+)
+
+func TestRangeQualifier_Valid(t *testing.T) {
+	cases := []struct {
+		params   [][2]string
+		expected string
+	}{
+		{[][2]string{{"stars", "1000"}}, "stars:1000"},
+		{[][2]string{{"stars", "0"}}, "stars:0"},
+		{[][2]string{{"stars", ">=1000"}}, "stars:>=1000"},
+		{[][2]string{{"stars_gt", "1000"}}, "stars:>1000"},
+		{[][2]string{{"forks", "10..*"}}, "forks:>=10"},
+		{[][2]string{{"size_gte", "10"}, {"size_lt", "50"}}, "size:10..49"},
+		{[][2]string{{"created", "2024-01-01..2024-06-30"}}, "created:2024-01-01..2024-06-30"},
+		{[][2]string{{"pushed_gt", "2024-01-01"}, {"pushed_lte", "2024-06-30"}}, "pushed:2024-01-02..2024-06-30"},
+		{[][2]string{{"created_lt", "2024-01-01T12:00:00Z"}}, "created:<2024-01-01T12:00:00Z"},
+	}
+
+	for _, c := range cases {
+		grb, _ := NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+		for _, param := range c.params {
+			if err := grb.With(param[0], param[1]); err != nil {
+				t.Fatalf("%s=%s should be supported, got %s", param[0], param[1], err)
+			}
+		}
+
+		req, _ := grb.Build(context.Background(), http.MethodGet, "/search/repositories")
+
+		if !strings.Contains(req.URL.String(), url.QueryEscape(c.expected)) {
+			t.Fatalf("Built request URL %s is missing %s", req.URL.String(), c.expected)
+		}
+	}
+}
+
+func TestRangeQualifier_Invalid(t *testing.T) {
+	cases := []struct {
+		params   [][2]string
+		expected string
+	}{
+		{[][2]string{{"stars", "many"}}, `stars parameter value "many" must be a non-negative integer`},
+		{[][2]string{{"created_gte", "2024-13-01"}}, `created_gte parameter value "2024-13-01" must be a date`},
+		{[][2]string{{"forks", "50..10"}}, "forks parameter results in an empty range between 50 and 10"},
+		{[][2]string{{"stars_gt", "10"}, {"stars_lt", "10"}}, "stars_lt parameter results in an empty range"},
+		{[][2]string{{"stars", "10"}, {"stars_gte", "5"}}, "stars_gte parameter conflicts with a lower bound already set to 10"},
+		{[][2]string{{"stars_gte", ">5"}}, `stars_gte parameter value ">5" must be a non-negative integer`},
+		{[][2]string{{"watchers_gte", "5"}}, "watchers_gte parameter is not supported"},
+	}
+
+	for _, c := range cases {
+		grb, _ := NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+		var err error
+		for _, param := range c.params {
+			if err = grb.With(param[0], param[1]); err != nil {
+				break
+			}
+		}
+
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Fatalf("Should have returned an error containing %q, got %v", c.expected, err)
+		}
+	}
+}
+
+func TestRangeQualifier_CreatedWindow(t *testing.T) {
+	grb, _ := NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	_ = grb.With("created_gte", "2024-01-01")
+
+	from := time.Date(2023, time.June, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	window, err := grb.CreatedWindow(from, to)
+
+	if err != nil {
+		t.Fatalf("Window intersecting the created parameter should be supported")
+	}
+
+	req, _ := window.Build(context.Background(), http.MethodGet, "/search/repositories")
+	if !strings.Contains(req.URL.Query().Get("q"), "created:2024-01-01..2024-06-01T12:00:00Z") {
+		t.Fatalf("Window should have been restricted to the created parameter, got %s", req.URL.Query().Get("q"))
+	}
+
+	req, _ = grb.Build(context.Background(), http.MethodGet, "/search/repositories")
+	if !strings.Contains(req.URL.Query().Get("q"), "created:>=2024-01-01") {
+		t.Fatalf("Window should not modify the original request")
+	}
+
+	if _, err := grb.CreatedWindow(from, from.Add(time.Hour)); err == nil {
+		t.Fatalf("Window not intersecting the created parameter should return an error")
+	}
+}
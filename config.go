@@ -6,12 +6,22 @@ import (
 )
 
 type Config struct {
-	Port               int    `envconfig:"PORT" default:"5000"`
-	GithubToken        string `envconfig:"GITHUB_TOKEN" default:""`
-	GithubApiVersion   string `envconfig:"GITHUB_API_VERSION" default:"2022-11-28"`
-	RedisPassword      string `envconfig:"REDIS_PASSWORD" default:""`
-	RedisPort          int    `envconfig:"REDIS_PORT" default:"6379"`
-	CacheDurationInMin int    `envconfig:"CACHE_DURATION_IN_MIN" default:"5"`
+	Port                       int      `envconfig:"PORT" default:"5000"`
+	GithubToken                string   `envconfig:"GITHUB_TOKEN" default:""`
+	GithubTokens               []string `envconfig:"GITHUB_TOKENS" default:""`
+	GithubApiVersion           string   `envconfig:"GITHUB_API_VERSION" default:"2022-11-28"`
+	GithubApi                  string   `envconfig:"GITHUB_API" default:"rest"`
+	CacheBackend               string   `envconfig:"CACHE_BACKEND" default:"redis"`
+	MemoryCacheEntries         int      `envconfig:"MEMORY_CACHE_MAX_ENTRIES" default:"10000"`
+	MemoryCacheBytes           int      `envconfig:"MEMORY_CACHE_MAX_BYTES" default:"67108864"`
+	LocalCacheDurationInSec    int      `envconfig:"LOCAL_CACHE_DURATION_IN_SEC" default:"30"`
+	DistributedCoalescing      bool     `envconfig:"DISTRIBUTED_COALESCING" default:"false"`
+	RedisPassword              string   `envconfig:"REDIS_PASSWORD" default:""`
+	RedisPort                  int      `envconfig:"REDIS_PORT" default:"6379"`
+	CacheDurationInMin         int      `envconfig:"CACHE_DURATION_IN_MIN" default:"5"`
+	StaleDurationInMin         int      `envconfig:"STALE_DURATION_IN_MIN" default:"5"`
+	ReleasesCacheDurationInMin int      `envconfig:"RELEASES_CACHE_DURATION_IN_MIN" default:"60"`
+	CursorSecret               string   `envconfig:"CURSOR_SECRET" default:""`
 }
 
 func newConfig() (*Config, error) {
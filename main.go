@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"net/http"
 	"os"
@@ -28,49 +29,123 @@ func main() {
 		os.Exit(1)
 	}
 
-	if cfg.GithubToken == "" {
+	githubTokens := append([]string{cfg.GithubToken}, cfg.GithubTokens...)
+	if cfg.GithubToken == "" && len(cfg.GithubTokens) == 0 {
 		log.Warn("Booting without the use of a Github token: the application will run in limited mode")
 	}
 
 	log.Info("Initializing Providers")
-	httpProvider := providers.NewNativeHttpProvider(providers.NativeHttpClient{
+	tokenProvider := providers.NewTokenPool(githubTokens)
+	httpProvider := providers.NewNativeHttpProvider(providers.NewTokenObservingHttpClient(providers.NativeHttpClient{
 		Do: http.DefaultClient.Do,
-	})
+	}, tokenProvider))
 	log.WithFields(logrus.Fields{"HttpClient": "Native"}).Info("HTTP")
 
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("redis:%d", cfg.RedisPort),
-		Password: cfg.RedisPassword,
-		DB:       0, // Use default DB
-		Protocol: 2, // Connection protocol
-	})
+	// The in memory cache can't evict its way under non positive limits
+	if (cfg.CacheBackend == "memory" || cfg.CacheBackend == "layered") && (cfg.MemoryCacheEntries <= 0 || cfg.MemoryCacheBytes <= 0) {
+		log.Fatalf("could not initialize cache: MEMORY_CACHE_MAX_ENTRIES (%d) and MEMORY_CACHE_MAX_BYTES (%d) must be greater than 0", cfg.MemoryCacheEntries, cfg.MemoryCacheBytes)
+	}
 
-	if err := rdb.Ping(context.Background()).Err(); err != nil {
-		log.Fatalf("could not connect to redis: %s", err.Error())
+	var cacheProvider providers.CacheProvider
+	var rdb *redis.Client
+	switch cfg.CacheBackend {
+	case "redis":
+		rdb, err = connectRedis(cfg)
+		if err != nil {
+			log.Fatalf("could not connect to redis: %s", err.Error())
+		}
+
+		cacheProvider = providers.NewRedisCacheProvider(&providers.RedisClient{
+			Get:  rdb.Get,
+			Set:  rdb.Set,
+			PTTL: rdb.PTTL,
+		})
+		log.WithFields(logrus.Fields{"CacheClient": "Redis"}).Info("Cache")
+	case "layered":
+		rdb, err = connectRedis(cfg)
+		if err != nil {
+			log.Fatalf("could not connect to redis: %s", err.Error())
+		}
+
+		cacheProvider = providers.NewLayeredCacheProvider(
+			providers.NewMemoryCacheProvider(cfg.MemoryCacheEntries, cfg.MemoryCacheBytes),
+			providers.NewRedisCacheProvider(&providers.RedisClient{
+				Get:  rdb.Get,
+				Set:  rdb.Set,
+				PTTL: rdb.PTTL,
+			}),
+			time.Second*time.Duration(cfg.LocalCacheDurationInSec),
+		)
+		log.WithFields(logrus.Fields{"CacheClient": "Memory+Redis", "MaxEntries": cfg.MemoryCacheEntries, "MaxBytes": cfg.MemoryCacheBytes}).Info("Cache")
+	case "memory":
+		cacheProvider = providers.NewMemoryCacheProvider(cfg.MemoryCacheEntries, cfg.MemoryCacheBytes)
+		log.WithFields(logrus.Fields{"CacheClient": "Memory", "MaxEntries": cfg.MemoryCacheEntries, "MaxBytes": cfg.MemoryCacheBytes}).Info("Cache")
+	default:
+		log.Fatalf("could not initialize cache: unsupported cache backend %s", cfg.CacheBackend)
 	}
 
-	cacheProvider := providers.NewRedisCacheProvider(&providers.RedisClient{
-		Get: rdb.Get,
-		Set: rdb.Set,
-	})
-	log.WithFields(logrus.Fields{"CacheClient": "Redis"}).Info("Cache")
+	var lockProvider providers.LockProvider
+	if cfg.DistributedCoalescing {
+		if rdb == nil {
+			log.Fatalf("could not initialize distributed coalescing: it requires the redis or layered cache backend")
+		}
+
+		lockProvider = providers.NewRedisLockProvider(&providers.RedisLockClient{
+			SetNX: rdb.SetNX,
+			Eval:  rdb.Eval,
+		})
+	}
+	log.WithFields(logrus.Fields{"Distributed": cfg.DistributedCoalescing}).Info("Coalescing")
 
 	log.WithFields(logrus.Fields{}).Info("Initializing services")
-	githubApiRepository, err := repositories.NewGithubApiRepository(
-		version.GithubAPIVersion(cfg.GithubApiVersion),
-		httpProvider,
-		cacheProvider,
-		time.Duration(cfg.CacheDurationInMin),
-		cfg.GithubToken,
-	)
-	if err != nil {
-		log.Fatalf("could not initialize github repository: %s", err.Error())
+	var githubApiRepository repositories.GithubApiRepository
+	switch cfg.GithubApi {
+	case "rest":
+		githubApiRepository, err = repositories.NewGithubApiRepository(
+			version.GithubAPIVersion(cfg.GithubApiVersion),
+			httpProvider,
+			cacheProvider,
+			time.Duration(cfg.CacheDurationInMin),
+			time.Duration(cfg.ReleasesCacheDurationInMin),
+			tokenProvider,
+		)
+		if err != nil {
+			log.Fatalf("could not initialize github repository: %s", err.Error())
+		}
+	case "graphql":
+		if cfg.GithubToken == "" && len(cfg.GithubTokens) == 0 {
+			log.Fatalf("could not initialize github repository: the GraphQL API requires a Github token")
+		}
+		githubApiRepository = repositories.NewGithubGraphQLApiRepository(
+			repositories.GITHUB_GRAPHQL_API_URL,
+			httpProvider,
+			cacheProvider,
+			time.Duration(cfg.CacheDurationInMin),
+			time.Duration(cfg.ReleasesCacheDurationInMin),
+			tokenProvider,
+		)
+	default:
+		log.Fatalf("could not initialize github repository: unsupported github api %s", cfg.GithubApi)
 	}
+	log.WithFields(logrus.Fields{"GithubApi": cfg.GithubApi}).Info("Github")
 	githubService := services.NewGithubService(githubApiRepository)
 
+	// Cursors signed by a replica must be verified by every other replica
+	cursorSecret := []byte(cfg.CursorSecret)
+	if cfg.CursorSecret == "" {
+		cursorSecret = make([]byte, 32)
+		if _, err := rand.Read(cursorSecret); err != nil {
+			log.Fatalf("could not generate cursor secret: %s", err.Error())
+		}
+		log.Warn("Booting without a cursor secret: cursors are only valid for this replica until it restarts")
+	}
+
 	log.Info("Initializing routes")
 	router := handlers.NewRouter(log)
-	router.HandleFunc("/repos", handlers.HandlerFunc(api.GitHubProjectsHandler(githubService, cacheProvider, time.Duration(cfg.CacheDurationInMin), version.GithubAPIVersion(cfg.GithubApiVersion))))
+	router.HandleFunc("/repos", handlers.HandlerFunc(api.GitHubProjectsHandler(githubService, cacheProvider, lockProvider, time.Duration(cfg.CacheDurationInMin), time.Duration(cfg.StaleDurationInMin), cursorSecret, version.GithubAPIVersion(cfg.GithubApiVersion))))
+	router.HandleFunc("/stats/languages", handlers.HandlerFunc(api.GitHubLanguagesStatsHandler(githubService, cacheProvider, time.Duration(cfg.CacheDurationInMin), version.GithubAPIVersion(cfg.GithubApiVersion))))
+	router.HandleFunc("/rate_limit", handlers.HandlerFunc(api.GitHubRateLimitHandler(githubService, version.GithubAPIVersion(cfg.GithubApiVersion))))
+	router.HandleFunc("/repos/{owner}/{name}", handlers.HandlerFunc(api.GitHubProjectHandler(githubService, cacheProvider, time.Duration(cfg.CacheDurationInMin), version.GithubAPIVersion(cfg.GithubApiVersion))))
 
 	log = log.WithField("port", cfg.Port)
 	log.Info("Listening...")
@@ -81,3 +156,19 @@ func main() {
 		os.Exit(2)
 	}
 }
+
+// Creates a redis client and ensures that redis is reachable
+func connectRedis(cfg *Config) (*redis.Client, error) {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("redis:%d", cfg.RedisPort),
+		Password: cfg.RedisPassword,
+		DB:       0, // Use default DB
+		Protocol: 2, // Connection protocol
+	})
+
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		return nil, err
+	}
+
+	return rdb, nil
+}
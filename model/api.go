@@ -8,9 +8,21 @@ type ApiListResponse[T any] struct {
 	Count            int                            `json:"count"`
 	Content          T                              `json:"content"`
 	IncompleteResult bool                           `json:"incomplete_result"`
-	Page             int                            `json:"page,omitempty"`
+	Errors           []RepositoryError              `json:"errors"`
+	Sort             string                         `json:"sort,omitempty"`
+	Order            string                         `json:"order,omitempty"`
+	Page             int                            `json:"page"`
+	TotalPages       int                            `json:"total_pages"`
+	HasNext          bool                           `json:"has_next"`
+	HasPrevious      bool                           `json:"has_previous"`
 	Previous         util.NullableJsonField[string] `json:"previous,omitempty"`
-	Next             string                         `json:"next,omitempty"`
+	Next             util.NullableJsonField[string] `json:"next"`
+}
+
+// Used for single repository responses whose fields failed, these fields are null and their failures are listed in Errors
+type ApiRepositoryResponse struct {
+	*Repository
+	Errors []RepositoryError `json:"errors"`
 }
 
 // Used for bad response
@@ -1,6 +1,10 @@
 package external
 
-import "github.com/LasramR/sclng-backend-test-lasramR/util"
+import (
+	"time"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/util"
+)
 
 // Represents a response from https://api.github.com/search/repositories
 type RepositoriesResponse struct {
@@ -17,19 +21,31 @@ func (r RepositoriesResponse) Count() int {
 	return r.TotalCount
 }
 
-// Represents an item from a RepositoriesResponse
+// Represents an item from a RepositoriesResponse, PushedAt is null for repositories without commits
 type RepositoriesResponseItem struct {
-	Id           int                                 `json:"id"`
-	Name         string                              `json:"name"`
-	FullName     string                              `json:"full_name"`
-	Description  string                              `json:"description"`
-	Owner        ItemOwner                           `json:"owner"`
-	Url          string                              `json:"url"`
-	LanguagesUrl string                              `json:"languages_url"`
-	License      util.NullableJsonField[ItemLicense] `json:"license"`
-	CreatedAt    string                              `json:"created_at"`
-	UpdatedAt    string                              `json:"updated_at"`
-	Size         int                                 `json:"size"`
+	Id              int                                 `json:"id"`
+	Name            string                              `json:"name"`
+	FullName        string                              `json:"full_name"`
+	Description     string                              `json:"description"`
+	Owner           ItemOwner                           `json:"owner"`
+	Url             string                              `json:"url"`
+	LanguagesUrl    string                              `json:"languages_url"`
+	License         util.NullableJsonField[ItemLicense] `json:"license"`
+	CreatedAt       time.Time                           `json:"created_at"`
+	UpdatedAt       time.Time                           `json:"updated_at"`
+	PushedAt        util.NullableJsonField[time.Time]   `json:"pushed_at"`
+	Size            int                                 `json:"size"`
+	StargazersCount int                                 `json:"stargazers_count"`
+	ForksCount      int                                 `json:"forks_count"`
+	OpenIssuesCount int                                 `json:"open_issues_count"`
+	WatchersCount   int                                 `json:"watchers_count"`
+	Topics          []string                            `json:"topics"`
+	DefaultBranch   string                              `json:"default_branch"`
+	Archived        bool                                `json:"archived"`
+	Fork            bool                                `json:"fork"`
+	Homepage        util.NullableJsonField[string]      `json:"homepage"`
+	Visibility      string                              `json:"visibility"`
+	Language        util.NullableJsonField[string]      `json:"language"`
 }
 
 // Represents an item's owner from a RepositoriesResponseItem
@@ -39,8 +55,49 @@ type ItemOwner struct {
 
 // Represents an item's license from a RepositoriesResponseItem
 type ItemLicense struct {
-	Key string `json:"key"`
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	SpdxId string `json:"spdx_id"`
 }
 
 // Represents a response from a language_url of a RepositoriesResponseItem
 type Languages map[string]int
+
+// Represents an item of a response from https://api.github.com/repos/{owner}/{repo}/contributors
+type Contributor struct {
+	Login         string `json:"login"`
+	Contributions int    `json:"contributions"`
+}
+
+// Represents an item of a response from https://api.github.com/repos/{owner}/{repo}/stats/commit_activity, Week is an epoch in seconds
+type CommitActivityWeek struct {
+	Days  []int `json:"days"`
+	Total int   `json:"total"`
+	Week  int64 `json:"week"`
+}
+
+// Represents a response from https://api.github.com/repos/{owner}/{repo}/releases/latest, PublishedAt is null for draft releases
+type Release struct {
+	TagName     string                            `json:"tag_name"`
+	Name        string                            `json:"name"`
+	Prerelease  bool                              `json:"prerelease"`
+	PublishedAt util.NullableJsonField[time.Time] `json:"published_at"`
+}
+
+// Represents an item of a response from https://api.github.com/repos/{owner}/{repo}/tags
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// Represents a response from https://api.github.com/rate_limit
+type RateLimitResponse struct {
+	Resources map[string]RateLimitResponseResource `json:"resources"`
+}
+
+// Represents a resource from a RateLimitResponse, Reset is an epoch in seconds
+type RateLimitResponseResource struct {
+	Limit     int   `json:"limit"`
+	Remaining int   `json:"remaining"`
+	Used      int   `json:"used"`
+	Reset     int64 `json:"reset"`
+}
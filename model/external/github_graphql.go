@@ -0,0 +1,114 @@
+package external
+
+import (
+	"time"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/util"
+)
+
+// Represents a request to https://api.github.com/graphql
+type GraphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables"`
+}
+
+// Represents an error from a GraphQL response
+type GraphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// Represents a response from the GraphQL search query
+type GraphQLSearchResponse struct {
+	Data struct {
+		Search struct {
+			RepositoryCount int                     `json:"repositoryCount"`
+			Nodes           []GraphQLRepositoryNode `json:"nodes"`
+		} `json:"search"`
+	} `json:"data"`
+	Errors []GraphQLError `json:"errors"`
+}
+
+func (r GraphQLSearchResponse) Items() []GraphQLRepositoryNode {
+	return r.Data.Search.Nodes
+}
+
+func (r GraphQLSearchResponse) Count() int {
+	return r.Data.Search.RepositoryCount
+}
+
+// Represents a response from the GraphQL repository query
+type GraphQLRepositoryResponse struct {
+	Data struct {
+		Repository util.NullableJsonField[GraphQLRepositoryNode] `json:"repository"`
+	} `json:"data"`
+	Errors []GraphQLError `json:"errors"`
+}
+
+// Represents a repository node of a GraphQL response
+type GraphQLRepositoryNode struct {
+	Name             string                                     `json:"name"`
+	NameWithOwner    string                                     `json:"nameWithOwner"`
+	Description      string                                     `json:"description"`
+	Url              string                                     `json:"url"`
+	Owner            ItemOwner                                  `json:"owner"`
+	LicenseInfo      util.NullableJsonField[GraphQLLicenseInfo] `json:"licenseInfo"`
+	CreatedAt        time.Time                                  `json:"createdAt"`
+	UpdatedAt        time.Time                                  `json:"updatedAt"`
+	PushedAt         util.NullableJsonField[time.Time]          `json:"pushedAt"`
+	DiskUsage        int                                        `json:"diskUsage"`
+	Languages        GraphQLLanguageConnection                  `json:"languages"`
+	StargazerCount   int                                        `json:"stargazerCount"`
+	ForkCount        int                                        `json:"forkCount"`
+	Issues           GraphQLCount                               `json:"issues"`
+	Watchers         GraphQLCount                               `json:"watchers"`
+	RepositoryTopics GraphQLTopicConnection                     `json:"repositoryTopics"`
+	DefaultBranchRef util.NullableJsonField[GraphQLRef]         `json:"defaultBranchRef"`
+	IsArchived       bool                                       `json:"isArchived"`
+	IsFork           bool                                       `json:"isFork"`
+	HomepageUrl      util.NullableJsonField[string]             `json:"homepageUrl"`
+	Visibility       string                                     `json:"visibility"`
+	PrimaryLanguage  util.NullableJsonField[GraphQLLanguage]    `json:"primaryLanguage"`
+}
+
+// Represents a repository license of a GraphQLRepositoryNode
+type GraphQLLicenseInfo struct {
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	SpdxId string `json:"spdxId"`
+}
+
+// Represents the total count of a GraphQL connection
+type GraphQLCount struct {
+	TotalCount int `json:"totalCount"`
+}
+
+// Represents the topics of a GraphQLRepositoryNode
+type GraphQLTopicConnection struct {
+	Nodes []struct {
+		Topic struct {
+			Name string `json:"name"`
+		} `json:"topic"`
+	} `json:"nodes"`
+}
+
+// Represents a git reference of a GraphQLRepositoryNode
+type GraphQLRef struct {
+	Name string `json:"name"`
+}
+
+// Represents the languages of a GraphQLRepositoryNode
+type GraphQLLanguageConnection struct {
+	Edges []GraphQLLanguageEdge `json:"edges"`
+}
+
+// Represents a language of a GraphQLLanguageConnection, Size is expressed in bytes
+type GraphQLLanguageEdge struct {
+	Size int             `json:"size"`
+	Node GraphQLLanguage `json:"node"`
+}
+
+// Represents a language of a GraphQL response
+type GraphQLLanguage struct {
+	Name string `json:"name"`
+}
@@ -1,6 +1,13 @@
 package model
 
-import "github.com/LasramR/sclng-backend-test-lasramR/util"
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/util"
+)
 
 type Repository struct {
 	FullName      string                         `json:"full_name"`
@@ -8,15 +15,153 @@ type Repository struct {
 	Description   string                         `json:"description"`
 	Repository    string                         `json:"repository"`
 	RepositoryUrl string                         `json:"repository_url"`
-	Languages     Language                       `json:"languages"`
-	License       util.NullableJsonField[string] `json:"license"`
-	Size          int                            `json:"size"`
-	CreatedAt     string                         `json:"created_at"`
-	UpdatedAt     string                         `json:"updated_at"`
+	Homepage      util.NullableJsonField[string] `json:"homepage"`
+	Languages     Languages                      `json:"languages"`
+	// Main language of the repository as detected by Github, available without fetching Languages
+	PrimaryLanguage util.NullableJsonField[string]    `json:"primary_language"`
+	License         util.NullableJsonField[License]   `json:"license"`
+	Topics          []string                          `json:"topics"`
+	DefaultBranch   string                            `json:"default_branch"`
+	Visibility      string                            `json:"visibility"`
+	Archived        bool                              `json:"archived"`
+	Fork            bool                              `json:"fork"`
+	Stars           int                               `json:"stars"`
+	Forks           int                               `json:"forks"`
+	OpenIssues      int                               `json:"open_issues"`
+	Watchers        int                               `json:"watchers"`
+	Size            int                               `json:"size"`
+	CreatedAt       time.Time                         `json:"created_at"`
+	UpdatedAt       time.Time                         `json:"updated_at"`
+	PushedAt        util.NullableJsonField[time.Time] `json:"pushed_at"`
+	// Enrichments fetched on demand, nil unless expanded and null if they could not be fetched
+	Contributors *util.NullableJsonField[Contributors] `json:"contributors,omitempty"`
+	Activity     *util.NullableJsonField[Activity]     `json:"activity,omitempty"`
+	Releases     *util.NullableJsonField[Releases]     `json:"releases,omitempty"`
+}
+
+// Failure of a field of the repository at Index of a list, the field is null in the repository.
+// Enrichment is the failed field eg languages, empty if the whole repository could not be fetched
+type RepositoryError struct {
+	Index      int    `json:"index"`
+	FullName   string `json:"full_name"`
+	Enrichment string `json:"enrichment"`
+	Reason     string `json:"reason"`
+}
+
+// License of a repository, SpdxId is the SPDX identifier of the license eg MIT
+type License struct {
+	Key    string `json:"key"`
+	SpdxId string `json:"spdx_id"`
+	Name   string `json:"name"`
+}
+
+// Top contributors of a repository by decreasing contributions, Pending is true while Github is computing them
+type Contributors struct {
+	Pending bool          `json:"pending"`
+	Top     []Contributor `json:"top"`
+}
+
+type Contributor struct {
+	Login string `json:"login"`
+	// Number of commits authored by the contributor
+	Contributions int `json:"contributions"`
+}
+
+// Commit activity of a repository over the last year, Pending is true while Github is computing it
+type Activity struct {
+	Pending      bool `json:"pending"`
+	TotalCommits int  `json:"total_commits"`
+	// Commits per week, oldest week first
+	Weeks []WeeklyActivity `json:"weeks"`
+}
+
+type WeeklyActivity struct {
+	// First day of the week, a sunday
+	Week    time.Time `json:"week"`
+	Commits int       `json:"commits"`
+}
+
+// Releases of a repository, Latest is null if the repository has neither release nor tag
+type Releases struct {
+	Latest util.NullableJsonField[Release] `json:"latest"`
+}
+
+// Release of a repository, tags without Github release have no published date and are never prereleases
+type Release struct {
+	Version     string                            `json:"version"`
+	PublishedAt util.NullableJsonField[time.Time] `json:"published_at"`
+	Prerelease  bool                              `json:"prerelease"`
 }
 
-type Language map[string]LanguageStats
+// Name of the language holding the languages folded by Languages.Fold
+const OTHER_LANGUAGE = "Other"
+
+// Languages of a repository ordered by rank, a JSON map would lose this order
+type Languages []LanguageStats
 
 type LanguageStats struct {
-	Bytes int `json:"bytes"`
+	Name  string `json:"name"`
+	Bytes int    `json:"bytes"`
+	// Share of the repository bytes written in this language, between 0 and 100
+	Percentage float64 `json:"percentage"`
+	// Position of the language by decreasing bytes, starting at 1
+	Rank int `json:"rank"`
+}
+
+// Creates the ranked languages of a repository from the bytes of each language.
+// Languages with the same bytes are ranked by name so that the order is deterministic
+func NewLanguages(bytesPerLanguage map[string]int) Languages {
+	languages := make(Languages, 0, len(bytesPerLanguage))
+	for name, bytes := range bytesPerLanguage {
+		languages = append(languages, LanguageStats{Name: name, Bytes: bytes})
+	}
+
+	slices.SortFunc(languages, func(a, b LanguageStats) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(a.Name, b.Name))
+	})
+
+	return languages.ranked()
+}
+
+// Returns a copy of the languages where languages holding less than minShare percents are folded into a single OTHER_LANGUAGE ranked last.
+// Languages that failed to be fetched stay nil
+func (languages Languages) Fold(minShare float64) Languages {
+	if languages == nil {
+		return nil
+	}
+
+	folded := make(Languages, 0, len(languages))
+	other := LanguageStats{Name: OTHER_LANGUAGE}
+
+	for _, language := range languages {
+		if language.Percentage < minShare {
+			other.Bytes += language.Bytes
+		} else {
+			folded = append(folded, language)
+		}
+	}
+
+	if len(folded) == len(languages) {
+		return folded
+	}
+
+	return append(folded, other).ranked()
+}
+
+// Computes the percentage and rank of already ordered languages, percentages are rounded to two decimals
+func (languages Languages) ranked() Languages {
+	total := 0
+	for _, language := range languages {
+		total += language.Bytes
+	}
+
+	for i := range languages {
+		languages[i].Rank = i + 1
+		languages[i].Percentage = 0
+		if total != 0 {
+			languages[i].Percentage = math.Round(float64(languages[i].Bytes)*10000/float64(total)) / 100
+		}
+	}
+
+	return languages
 }
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNewLanguages(t *testing.T) {
+	languages := NewLanguages(map[string]int{"Go": 600, "Shell": 200, "Makefile": 200})
+
+	expected := Languages{
+		{Name: "Go", Bytes: 600, Percentage: 60, Rank: 1},
+		{Name: "Makefile", Bytes: 200, Percentage: 20, Rank: 2},
+		{Name: "Shell", Bytes: 200, Percentage: 20, Rank: 3},
+	}
+
+	if !reflect.DeepEqual(languages, expected) {
+		t.Fatalf("Should have ranked languages by bytes then name, got %v", languages)
+	}
+
+	if empty := NewLanguages(map[string]int{}); len(empty) != 0 {
+		t.Fatalf("Should have returned no languages")
+	}
+}
+
+func TestLanguages_Fold(t *testing.T) {
+	languages := NewLanguages(map[string]int{"Go": 900, "Shell": 60, "Makefile": 40})
+
+	folded := languages.Fold(10)
+
+	expected := Languages{
+		{Name: "Go", Bytes: 900, Percentage: 90, Rank: 1},
+		{Name: OTHER_LANGUAGE, Bytes: 100, Percentage: 10, Rank: 2},
+	}
+
+	if !reflect.DeepEqual(folded, expected) {
+		t.Fatalf("Should have folded languages under 10 percents, got %v", folded)
+	}
+
+	if len(languages) != 3 || languages[1].Name != "Shell" {
+		t.Fatalf("Fold should not modify the original languages")
+	}
+
+	if unchanged := languages.Fold(1); !reflect.DeepEqual(unchanged, languages) {
+		t.Fatalf("Should not have folded any language, got %v", unchanged)
+	}
+	if folded := Languages(nil).Fold(10); folded != nil {
+		t.Fatalf("Languages that failed to be fetched should stay nil, got %v", folded)
+	}
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// Rate limit state of the Github API for the token used by the app
+type RateLimit struct {
+	Core    RateLimitResource `json:"core"`
+	Search  RateLimitResource `json:"search"`
+	GraphQL RateLimitResource `json:"graphql"`
+}
+
+// Rate limit state of a Github API resource
+type RateLimitResource struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Used      int       `json:"used"`
+	Reset     time.Time `json:"reset"`
+}
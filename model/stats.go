@@ -0,0 +1,26 @@
+package model
+
+// Aggregated language statistics computed accross many repositories
+type LanguagesStats struct {
+	// Number of repositories used to compute the stats
+	RepositoryCount int `json:"repository_count"`
+	// Sum of the bytes of every language accross every repositories
+	TotalBytes int `json:"total_bytes"`
+	// Set to true if some repositories could not be aggregated
+	IncompleteResult bool                               `json:"incomplete_result"`
+	Languages        map[string]AggregatedLanguageStats `json:"languages"`
+}
+
+// Statistics of a single language accross many repositories
+type AggregatedLanguageStats struct {
+	// Sum of the bytes of this language accross every repositories
+	TotalBytes int `json:"total_bytes"`
+	// Number of repositories using this language
+	RepositoryCount int `json:"repository_count"`
+	// Share of TotalBytes over the bytes of every language, between 0 and 1
+	Share float64 `json:"share"`
+	// Mean bytes per repository using this language
+	MeanBytes float64 `json:"mean_bytes"`
+	// Median bytes per repository using this language
+	MedianBytes float64 `json:"median_bytes"`
+}
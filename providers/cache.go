@@ -3,6 +3,7 @@ package providers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
 	redis "github.com/redis/go-redis/v9"
@@ -16,10 +17,19 @@ type CacheProvider interface {
 	SetMarshalled(ctx context.Context, key string, value any, expiresIn time.Duration) error
 }
 
+// CacheProvider able to tell when its elements expire
+type ExpiringCacheProvider interface {
+	CacheProvider
+	// Returns the remaining time to live of an element, 0 if it does not exist and a negative duration if it never expires
+	ExpiresIn(ctx context.Context, key string) (time.Duration, error)
+}
+
 // IoC of the Redis client, we dont rely on HSet and struct tags because we don't want to be tighly coupled to redis
 type RedisClient struct {
 	Get func(context.Context, string) *redis.StringCmd
 	Set func(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	// Optional, ExpiresIn fails without it
+	PTTL func(ctx context.Context, key string) *redis.DurationCmd
 }
 
 type redisCacheProvider struct {
@@ -46,11 +56,34 @@ func (r *redisCacheProvider) SetMarshalled(ctx context.Context, key string, valu
 	return r.client.Set(ctx, key, marshalled, expiresIn).Err()
 }
 
+func (r *redisCacheProvider) ExpiresIn(ctx context.Context, key string) (time.Duration, error) {
+	if r.client.PTTL == nil {
+		return 0, errors.New("redis client does not support PTTL")
+	}
+
+	ttl, err := r.client.PTTL(ctx, key).Result()
+
+	if err != nil {
+		return 0, err
+	}
+
+	// Redis responds with -2 when the key does not exist and -1 when it has no expiration
+	switch ttl {
+	case -2:
+		return 0, nil
+	case -1:
+		return -1, nil
+	}
+
+	return ttl, nil
+}
+
 func NewRedisCacheProvider(redisClient *RedisClient) CacheProvider {
 	return &redisCacheProvider{
 		client: &RedisClient{
-			Get: redisClient.Get,
-			Set: redisClient.Set,
+			Get:  redisClient.Get,
+			Set:  redisClient.Set,
+			PTTL: redisClient.PTTL,
 		},
 	}
 }
@@ -0,0 +1,48 @@
+package providers
+
+import (
+	"context"
+	"time"
+)
+
+// Wraps a cached value with its freshness, used for stale-while-revalidate caching :
+// the value is fresh until FreshUntil, then stale but still usable until StaleUntil while it is being refreshed
+type CacheEnvelope[T any] struct {
+	Value      T         `json:"value"`
+	FreshUntil time.Time `json:"fresh_until"`
+	StaleUntil time.Time `json:"stale_until"`
+}
+
+// Describes if the enveloped value is still fresh at the given time
+func (e CacheEnvelope[T]) IsFresh(now time.Time) bool {
+	return now.Before(e.FreshUntil)
+}
+
+// Wraps value in a CacheEnvelope fresh for freshFor and stale for staleFor afterward, then sets it in cache until it is no longer usable.
+// The envelope is returned even if it could not be set in cache
+func SetEnveloped[T any](ctx context.Context, cacheProvider CacheProvider, key string, value T, freshFor, staleFor time.Duration) (CacheEnvelope[T], error) {
+	now := time.Now()
+	envelope := CacheEnvelope[T]{
+		Value:      value,
+		FreshUntil: now.Add(freshFor),
+		StaleUntil: now.Add(freshFor + staleFor),
+	}
+
+	return envelope, cacheProvider.SetMarshalled(ctx, key, envelope, freshFor+staleFor)
+}
+
+// Retrieves a CacheEnvelope set with SetEnveloped, the caller must check its freshness
+func GetEnveloped[T any](ctx context.Context, cacheProvider CacheProvider, key string) (CacheEnvelope[T], error) {
+	var envelope CacheEnvelope[T]
+
+	if err := cacheProvider.GetUnmarshalled(ctx, key, &envelope); err != nil {
+		return CacheEnvelope[T]{}, err
+	}
+
+	// Cache backends may keep entries slightly longer than asked
+	if !time.Now().Before(envelope.StaleUntil) {
+		return CacheEnvelope[T]{}, ErrCacheMiss
+	}
+
+	return envelope, nil
+}
@@ -0,0 +1,44 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCacheEnvelope_Fresh(t *testing.T) {
+	cacheProvider := NewMemoryCacheProvider(10, 1024)
+	ctx := context.Background()
+
+	_, _ = SetEnveloped(ctx, cacheProvider, "some key", TestStruct{Key: "somefield"}, time.Minute, time.Minute)
+
+	envelope, err := GetEnveloped[TestStruct](ctx, cacheProvider, "some key")
+
+	if err != nil || envelope.Value.Key != "somefield" {
+		t.Fatalf("Enveloped value should be retrieved")
+	}
+
+	if !envelope.IsFresh(time.Now()) || envelope.IsFresh(time.Now().Add(90*time.Second)) {
+		t.Fatalf("Enveloped value should be fresh for the fresh duration only")
+	}
+
+	if !envelope.StaleUntil.Equal(envelope.FreshUntil.Add(time.Minute)) {
+		t.Fatalf("Enveloped value should be stale for the stale duration after being fresh")
+	}
+}
+
+func TestCacheEnvelope_Expired(t *testing.T) {
+	cacheProvider := NewMemoryCacheProvider(10, 1024)
+	ctx := context.Background()
+
+	// Sets an envelope that is no longer usable in a cache entry that never expires
+	_ = cacheProvider.SetMarshalled(ctx, "some key", CacheEnvelope[TestStruct]{
+		FreshUntil: time.Now().Add(-2 * time.Minute),
+		StaleUntil: time.Now().Add(-time.Minute),
+	}, 0)
+
+	if _, err := GetEnveloped[TestStruct](ctx, cacheProvider, "some key"); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("Envelope past its stale window should be a cache miss")
+	}
+}
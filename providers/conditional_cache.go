@@ -0,0 +1,48 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Cached payload of a conditional request along with the ETag it was responded with
+type ConditionalCacheEntry[T any] struct {
+	ETag    string `json:"etag"`
+	Payload T      `json:"payload"`
+}
+
+// Performs req through the cache, keyed by its url : a fresh cached payload is returned without requesting and a stale one is revalidated with If-None-Match.
+// A 304 response renews the freshness of the cached payload, which is cheaper than fetching it again (Github does not count it against the rate limit)
+func ReqCachedConditional[T any](ctx context.Context, httpProvider HttpProvider, cacheProvider CacheProvider, req *http.Request, freshFor, staleFor time.Duration) (T, error) {
+	key := req.URL.String()
+	cached, cacheErr := GetEnveloped[ConditionalCacheEntry[T]](ctx, cacheProvider, key)
+
+	if cacheErr == nil && cached.IsFresh(time.Now()) {
+		return cached.Value.Payload, nil
+	}
+
+	var etag string
+	if cacheErr == nil {
+		etag = cached.Value.ETag
+	}
+
+	var payload T
+	responseEtag, notModified, err := httpProvider.ReqConditionalUnmarshalledBody(req, etag, &payload)
+
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	if notModified {
+		payload = cached.Value.Payload
+	}
+
+	_, _ = SetEnveloped(ctx, cacheProvider, key, ConditionalCacheEntry[T]{
+		ETag:    responseEtag,
+		Payload: payload,
+	}, freshFor, staleFor)
+
+	return payload, nil
+}
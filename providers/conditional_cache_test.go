@@ -0,0 +1,101 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Fake Github API responding with a 304 when If-None-Match matches etag
+func MockConditionalHttpProvider(etag, body string, calls *int) HttpProvider {
+	return NewNativeHttpProvider(NativeHttpClient{
+		Do: func(req *http.Request) (*http.Response, error) {
+			*calls += 1
+
+			if req.Header.Get("If-None-Match") == etag {
+				return &http.Response{
+					StatusCode: http.StatusNotModified,
+					Body:       io.NopCloser(bytes.NewReader([]byte{})),
+				}, nil
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Etag": []string{etag}},
+				Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+			}, nil
+		},
+	})
+}
+
+func TestReqCachedConditional_Fresh(t *testing.T) {
+	calls := 0
+	httpProvider := MockConditionalHttpProvider(`"some etag"`, `{"key":"somefield"}`, &calls)
+	cacheProvider := NewMemoryCacheProvider(10, 1024)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "https://somedataendpoint.io", nil)
+		result, err := ReqCachedConditional[TestStruct](ctx, httpProvider, cacheProvider, req, time.Minute, time.Minute)
+
+		if err != nil || result.Key != "somefield" {
+			t.Fatalf("Should have returned the response payload")
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("Fresh cached payload should be returned without requesting, requested %d times", calls)
+	}
+}
+
+func TestReqCachedConditional_StaleNotModified(t *testing.T) {
+	calls := 0
+	httpProvider := MockConditionalHttpProvider(`"some etag"`, `{"key":"updated"}`, &calls)
+	cacheProvider := NewMemoryCacheProvider(10, 1024)
+	ctx := context.Background()
+
+	// Sets a stale entry with the same ETag than the server
+	_ = cacheProvider.SetMarshalled(ctx, "https://somedataendpoint.io", CacheEnvelope[ConditionalCacheEntry[TestStruct]]{
+		Value:      ConditionalCacheEntry[TestStruct]{ETag: `"some etag"`, Payload: TestStruct{Key: "somefield"}},
+		FreshUntil: time.Now().Add(-time.Minute),
+		StaleUntil: time.Now().Add(time.Minute),
+	}, 0)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://somedataendpoint.io", nil)
+	result, err := ReqCachedConditional[TestStruct](ctx, httpProvider, cacheProvider, req, time.Minute, time.Minute)
+
+	if err != nil || result.Key != "somefield" || calls != 1 {
+		t.Fatalf("Stale payload should have been revalidated and returned")
+	}
+
+	if envelope, err := GetEnveloped[ConditionalCacheEntry[TestStruct]](ctx, cacheProvider, "https://somedataendpoint.io"); err != nil || !envelope.IsFresh(time.Now()) {
+		t.Fatalf("Revalidated payload should be fresh again")
+	}
+}
+
+func TestReqCachedConditional_StaleModified(t *testing.T) {
+	calls := 0
+	httpProvider := MockConditionalHttpProvider(`"new etag"`, `{"key":"updated"}`, &calls)
+	cacheProvider := NewMemoryCacheProvider(10, 1024)
+	ctx := context.Background()
+
+	_ = cacheProvider.SetMarshalled(ctx, "https://somedataendpoint.io", CacheEnvelope[ConditionalCacheEntry[TestStruct]]{
+		Value:      ConditionalCacheEntry[TestStruct]{ETag: `"some etag"`, Payload: TestStruct{Key: "somefield"}},
+		FreshUntil: time.Now().Add(-time.Minute),
+		StaleUntil: time.Now().Add(time.Minute),
+	}, 0)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://somedataendpoint.io", nil)
+	result, err := ReqCachedConditional[TestStruct](ctx, httpProvider, cacheProvider, req, time.Minute, time.Minute)
+
+	if err != nil || result.Key != "updated" {
+		t.Fatalf("Modified payload should have been returned")
+	}
+
+	if envelope, _ := GetEnveloped[ConditionalCacheEntry[TestStruct]](ctx, cacheProvider, "https://somedataendpoint.io"); envelope.Value.ETag != `"new etag"` {
+		t.Fatalf("Modified payload should have been cached with its new ETag")
+	}
+}
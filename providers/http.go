@@ -2,13 +2,63 @@ package providers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 )
 
+// Returned by an HttpProvider when the requested resource does not exist
+var ErrNotFound = errors.New("resource not found")
+
+// Returned by an HttpProvider when the requested resource is still being computed (202), eg Github repository statistics
+var ErrAccepted = errors.New("resource is being computed")
+
+// Rate limit resources of the Github API, as described by the X-RateLimit-Resource response header
+const (
+	RATE_LIMIT_RESOURCE_CORE    = "core"
+	RATE_LIMIT_RESOURCE_SEARCH  = "search"
+	RATE_LIMIT_RESOURCE_GRAPHQL = "graphql"
+)
+
+// Rate limit state of an API as described by the X-RateLimit-* response headers
+type RateLimit struct {
+	Resource  string
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// Returned by an HttpProvider when the requested API rate limit has been exceeded
+type RateLimitError struct {
+	RateLimit RateLimit
+	// Duration to wait before retrying the request
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("api rate limit exceeded, retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+// Returned by an HttpProvider when the requested API responded with a non 2xx status
+type HttpStatusError struct {
+	StatusCode int
+}
+
+func (e *HttpStatusError) Error() string {
+	return fmt.Sprintf("api responded with unexpected status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
 // Allow to perform http related operations
 type HttpProvider interface {
-	// Perform a HTTP request and unmarshals the response body into unMarshalledResBody argument
+	// Perform a HTTP request and unmarshals the response body into unMarshalledResBody argument.
+	// error is ErrNotFound, a *RateLimitError or a *HttpStatusError if the response status is not 2xx, ErrAccepted if it is 202.
+	// unMarshalledResBody is left untouched if the response status is 204
 	ReqUnmarshalledBody(req *http.Request, unMarshalledResBody any) error
+	// Perform a HTTP request with the If-None-Match header set to etag when not empty.
+	// notModified is true and unMarshalledResBody is left untouched if the response status is 304, responseEtag is the ETag of the response otherwise
+	ReqConditionalUnmarshalledBody(req *http.Request, etag string, unMarshalledResBody any) (responseEtag string, notModified bool, err error)
 }
 
 // IoC of the http client
@@ -22,14 +72,116 @@ type nativeHttpProvider struct {
 }
 
 func (provider *nativeHttpProvider) ReqUnmarshalledBody(req *http.Request, unMarshalledResBody any) error {
+	_, _, err := provider.ReqConditionalUnmarshalledBody(req, "", unMarshalledResBody)
+	return err
+}
+
+func (provider *nativeHttpProvider) ReqConditionalUnmarshalledBody(req *http.Request, etag string, unMarshalledResBody any) (string, bool, error) {
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
 	response, err := provider.client.Do(req)
 
 	if err != nil {
-		return err
+		return "", false, err
 	}
 	defer response.Body.Close()
 
-	return json.NewDecoder(response.Body).Decode(unMarshalledResBody)
+	if etag != "" && response.StatusCode == http.StatusNotModified {
+		return etag, true, nil
+	}
+
+	if err = statusError(response); err != nil {
+		return "", false, err
+	}
+
+	switch response.StatusCode {
+	case http.StatusAccepted:
+		return "", false, ErrAccepted
+	case http.StatusNoContent:
+		return response.Header.Get("ETag"), false, nil
+	}
+
+	return response.Header.Get("ETag"), false, json.NewDecoder(response.Body).Decode(unMarshalledResBody)
+}
+
+// Returns the error corresponding to a non 2xx response, nil otherwise
+func statusError(response *http.Response) error {
+	if 200 <= response.StatusCode && response.StatusCode < 300 {
+		return nil
+	}
+
+	if response.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+
+	rateLimit, hasRateLimit := RateLimitFromHeader(response.Header)
+	retryAfter, hasRetryAfter := retryAfterFromHeader(response.Header)
+	exhausted := hasRateLimit && rateLimit.Remaining == 0
+
+	// Github responds with a 403 for primary rate limits and with a 403 or a 429 for secondary rate limits
+	if response.StatusCode == http.StatusTooManyRequests || (response.StatusCode == http.StatusForbidden && (exhausted || hasRetryAfter)) {
+		if !hasRetryAfter && exhausted {
+			retryAfter = max(time.Until(rateLimit.Reset), 0)
+		}
+
+		return &RateLimitError{
+			RateLimit:  rateLimit,
+			RetryAfter: retryAfter,
+		}
+	}
+
+	return &HttpStatusError{
+		StatusCode: response.StatusCode,
+	}
+}
+
+// Parses the X-RateLimit-* headers of a response, ok is false if the headers are missing or invalid
+func RateLimitFromHeader(header http.Header) (RateLimit, bool) {
+	limit, limitErr := strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	remaining, remainingErr := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	reset, resetErr := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+
+	if remainingErr != nil || resetErr != nil {
+		return RateLimit{}, false
+	}
+
+	// The limit is informative only, the remaining count and reset time are enough to describe the state
+	if limitErr != nil {
+		limit = remaining
+	}
+
+	resource := header.Get("X-RateLimit-Resource")
+	if resource == "" {
+		resource = RATE_LIMIT_RESOURCE_CORE
+	}
+
+	return RateLimit{
+		Resource:  resource,
+		Limit:     limit,
+		Remaining: remaining,
+		Reset:     time.Unix(reset, 0),
+	}, true
+}
+
+// Parses the Retry-After header of a response expressed either in seconds or as a HTTP date
+func retryAfterFromHeader(header http.Header) (time.Duration, bool) {
+	value := header.Get("Retry-After")
+
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
 }
 
 func NewNativeHttpProvider(client NativeHttpClient) HttpProvider {
@@ -3,10 +3,12 @@ package providers
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/LasramR/sclng-backend-test-lasramR/util"
 )
@@ -43,7 +45,8 @@ func TestReqUnmarshalledBody_DecodeFails(t *testing.T) {
 	httpProvider := NewNativeHttpProvider(MockHttpClient(
 		util.Result[*http.Response]{
 			Value: &http.Response{
-				Body: io.NopCloser(bytes.NewReader([]byte{})),
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader([]byte{})),
 			},
 			Error: nil,
 		},
@@ -63,7 +66,8 @@ func TestGetJson_Valid(t *testing.T) {
 	httpProvider := NewNativeHttpProvider(MockHttpClient(
 		util.Result[*http.Response]{
 			Value: &http.Response{
-				Body: io.NopCloser(bytes.NewReader([]byte("{\"key\":\"a\", \"value\":1}"))),
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader([]byte("{\"key\":\"a\", \"value\":1}"))),
 			},
 			Error: nil,
 		},
@@ -84,3 +88,191 @@ func TestGetJson_Valid(t *testing.T) {
 		t.Fatalf("should have fed our result struct")
 	}
 }
+
+func TestReqUnmarshalledBody_NotFound(t *testing.T) {
+	httpProvider := NewNativeHttpProvider(MockHttpClient(
+		util.Result[*http.Response]{
+			Value: &http.Response{
+				StatusCode: http.StatusNotFound,
+				Body:       io.NopCloser(bytes.NewReader([]byte(`{"message":"Not Found"}`))),
+			},
+			Error: nil,
+		},
+	))
+
+	req, _ := http.NewRequest(http.MethodGet, "https://somedataendpoint.io", nil)
+	var result GetUnmarshalledResponseT
+
+	err := httpProvider.ReqUnmarshalledBody(req, &result)
+
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("should return ErrNotFound when response status is 404")
+	}
+}
+
+func TestReqUnmarshalledBody_Accepted(t *testing.T) {
+	httpProvider := NewNativeHttpProvider(MockHttpClient(
+		util.Result[*http.Response]{
+			Value: &http.Response{
+				StatusCode: http.StatusAccepted,
+				Body:       io.NopCloser(bytes.NewReader([]byte(`{}`))),
+			},
+			Error: nil,
+		},
+	))
+
+	req, _ := http.NewRequest(http.MethodGet, "https://somedataendpoint.io", nil)
+	var result []GetUnmarshalledResponseT
+
+	err := httpProvider.ReqUnmarshalledBody(req, &result)
+
+	if !errors.Is(err, ErrAccepted) || result != nil {
+		t.Fatalf("should return ErrAccepted when response status is 202")
+	}
+}
+
+func TestReqUnmarshalledBody_NoContent(t *testing.T) {
+	httpProvider := NewNativeHttpProvider(MockHttpClient(
+		util.Result[*http.Response]{
+			Value: &http.Response{
+				StatusCode: http.StatusNoContent,
+				Body:       io.NopCloser(bytes.NewReader([]byte{})),
+			},
+			Error: nil,
+		},
+	))
+
+	req, _ := http.NewRequest(http.MethodGet, "https://somedataendpoint.io", nil)
+	var result []GetUnmarshalledResponseT
+
+	err := httpProvider.ReqUnmarshalledBody(req, &result)
+
+	if err != nil || result != nil {
+		t.Fatalf("should leave the result untouched when response status is 204")
+	}
+}
+
+func TestReqUnmarshalledBody_RateLimitExceeded(t *testing.T) {
+	reset := time.Now().Add(time.Minute)
+	httpProvider := NewNativeHttpProvider(MockHttpClient(
+		util.Result[*http.Response]{
+			Value: &http.Response{
+				StatusCode: http.StatusForbidden,
+				Header: http.Header{
+					"X-Ratelimit-Limit":     {"60"},
+					"X-Ratelimit-Remaining": {"0"},
+					"X-Ratelimit-Reset":     {fmt.Sprintf("%d", reset.Unix())},
+				},
+				Body: io.NopCloser(bytes.NewReader([]byte(`{"message":"API rate limit exceeded"}`))),
+			},
+			Error: nil,
+		},
+	))
+
+	req, _ := http.NewRequest(http.MethodGet, "https://somedataendpoint.io", nil)
+	var result GetUnmarshalledResponseT
+
+	err := httpProvider.ReqUnmarshalledBody(req, &result)
+
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) {
+		t.Fatalf("should return a RateLimitError when rate limit is exhausted")
+	}
+
+	if rateLimitErr.RateLimit.Limit != 60 || rateLimitErr.RetryAfter <= 0 || rateLimitErr.RetryAfter > time.Minute {
+		t.Fatalf("RateLimitError should describe the rate limit state and wait until reset")
+	}
+}
+
+func TestReqUnmarshalledBody_SecondaryRateLimit(t *testing.T) {
+	httpProvider := NewNativeHttpProvider(MockHttpClient(
+		util.Result[*http.Response]{
+			Value: &http.Response{
+				StatusCode: http.StatusTooManyRequests,
+				Header:     http.Header{"Retry-After": {"30"}},
+				Body:       io.NopCloser(bytes.NewReader([]byte{})),
+			},
+			Error: nil,
+		},
+	))
+
+	req, _ := http.NewRequest(http.MethodGet, "https://somedataendpoint.io", nil)
+	var result GetUnmarshalledResponseT
+
+	err := httpProvider.ReqUnmarshalledBody(req, &result)
+
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) || rateLimitErr.RetryAfter != 30*time.Second {
+		t.Fatalf("should return a RateLimitError honoring Retry-After")
+	}
+}
+
+func TestReqUnmarshalledBody_UnexpectedStatus(t *testing.T) {
+	httpProvider := NewNativeHttpProvider(MockHttpClient(
+		util.Result[*http.Response]{
+			Value: &http.Response{
+				StatusCode: http.StatusInternalServerError,
+				Body:       io.NopCloser(bytes.NewReader([]byte{})),
+			},
+			Error: nil,
+		},
+	))
+
+	req, _ := http.NewRequest(http.MethodGet, "https://somedataendpoint.io", nil)
+	var result GetUnmarshalledResponseT
+
+	err := httpProvider.ReqUnmarshalledBody(req, &result)
+
+	var statusErr *HttpStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("should return a HttpStatusError when response status is not 2xx")
+	}
+}
+
+func TestReqConditionalUnmarshalledBody_NotModified(t *testing.T) {
+	var ifNoneMatch string
+	httpProvider := NewNativeHttpProvider(NativeHttpClient{
+		Do: func(req *http.Request) (*http.Response, error) {
+			ifNoneMatch = req.Header.Get("If-None-Match")
+			return &http.Response{
+				StatusCode: http.StatusNotModified,
+				Body:       io.NopCloser(bytes.NewReader([]byte{})),
+			}, nil
+		},
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "https://somedataendpoint.io", nil)
+	var result GetUnmarshalledResponseT
+
+	etag, notModified, err := httpProvider.ReqConditionalUnmarshalledBody(req, `"some etag"`, &result)
+
+	if ifNoneMatch != `"some etag"` {
+		t.Fatalf("should have set the If-None-Match header")
+	}
+
+	if err != nil || !notModified || etag != `"some etag"` {
+		t.Fatalf("should report a not modified response when response status is 304")
+	}
+}
+
+func TestReqConditionalUnmarshalledBody_Modified(t *testing.T) {
+	httpProvider := NewNativeHttpProvider(MockHttpClient(
+		util.Result[*http.Response]{
+			Value: &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Etag": []string{`"new etag"`}},
+				Body:       io.NopCloser(bytes.NewReader([]byte("{\"key\":\"a\", \"value\":1}"))),
+			},
+			Error: nil,
+		},
+	))
+
+	req, _ := http.NewRequest(http.MethodGet, "https://somedataendpoint.io", nil)
+	var result GetUnmarshalledResponseT
+
+	etag, notModified, err := httpProvider.ReqConditionalUnmarshalledBody(req, `"some etag"`, &result)
+
+	if err != nil || notModified || etag != `"new etag"` || result.Key != "a" {
+		t.Fatalf("should have fed our result struct and returned the response ETag")
+	}
+}
@@ -0,0 +1,65 @@
+package providers
+
+import (
+	"context"
+	"time"
+)
+
+// CacheProvider checking a local cache before a remote one, local entries live at most localExpiresIn
+// so that replicas sharing the remote cache don't serve stale data for long. They never outlive the remote entry they were
+// back-filled from when the remote cache is an ExpiringCacheProvider
+type layeredCacheProvider struct {
+	local          CacheProvider
+	remote         CacheProvider
+	localExpiresIn time.Duration
+}
+
+func (l *layeredCacheProvider) GetUnmarshalled(ctx context.Context, key string, unmarshalledPayload any) error {
+	if err := l.local.GetUnmarshalled(ctx, key, unmarshalledPayload); err == nil {
+		return nil
+	}
+
+	if err := l.remote.GetUnmarshalled(ctx, key, unmarshalledPayload); err != nil {
+		return err
+	}
+
+	// Back-fill the local cache so that next reads on this replica skip the network round trip
+	localExpiresIn := l.localExpiresIn
+	if expiring, ok := l.remote.(ExpiringCacheProvider); ok {
+		remaining, err := expiring.ExpiresIn(ctx, key)
+
+		// The remote entry expired since it was read
+		if err == nil && remaining == 0 {
+			return nil
+		}
+
+		if err == nil && 0 < remaining && remaining < localExpiresIn {
+			localExpiresIn = remaining
+		}
+	}
+
+	_ = l.local.SetMarshalled(ctx, key, unmarshalledPayload, localExpiresIn)
+
+	return nil
+}
+
+func (l *layeredCacheProvider) SetMarshalled(ctx context.Context, key string, value any, expiresIn time.Duration) error {
+	localExpiresIn := l.localExpiresIn
+	if 0 < expiresIn && expiresIn < localExpiresIn {
+		localExpiresIn = expiresIn
+	}
+
+	// The local cache is best effort, the remote cache is the source of truth
+	_ = l.local.SetMarshalled(ctx, key, value, localExpiresIn)
+
+	return l.remote.SetMarshalled(ctx, key, value, expiresIn)
+}
+
+// Creates a CacheProvider reading through local then remote and writing through both, local entries expire after at most localExpiresIn
+func NewLayeredCacheProvider(local, remote CacheProvider, localExpiresIn time.Duration) CacheProvider {
+	return &layeredCacheProvider{
+		local:          local,
+		remote:         remote,
+		localExpiresIn: localExpiresIn,
+	}
+}
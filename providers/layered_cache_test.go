@@ -0,0 +1,105 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLayeredCache_BackFillsLocal(t *testing.T) {
+	local := NewMemoryCacheProvider(10, 1024)
+	remote := NewRedisCacheProvider(MockRedisCacheClient(`{"key":"somefield","value":1}`, nil, nil))
+	cacheProvider := NewLayeredCacheProvider(local, remote, time.Minute)
+	ctx := context.Background()
+
+	var s TestStruct
+	if err := cacheProvider.GetUnmarshalled(ctx, "some key", &s); err != nil {
+		t.Fatalf("Value in remote cache should be retrieved")
+	}
+
+	var fromLocal TestStruct
+	if err := local.GetUnmarshalled(ctx, "some key", &fromLocal); err != nil {
+		t.Fatalf("Value retrieved from remote cache should have been back-filled in local cache")
+	}
+
+	expected := TestStruct{Key: "somefield", Value: 1}
+	if !reflect.DeepEqual(s, expected) || !reflect.DeepEqual(fromLocal, expected) {
+		t.Fatalf("Values from both tiers should equal expected")
+	}
+}
+
+func TestLayeredCache_BackFillCappedByRemoteTTL(t *testing.T) {
+	local := NewMemoryCacheProvider(10, 1024)
+	now := time.Now()
+	local.(*memoryCacheProvider).now = func() time.Time { return now }
+	redisClient := MockRedisCacheClient(`{"key":"somefield","value":1}`, nil, nil)
+	redisClient.PTTL = func(ctx context.Context, key string) *redis.DurationCmd {
+		cmd := &redis.DurationCmd{}
+		cmd.SetVal(10 * time.Second)
+		return cmd
+	}
+	cacheProvider := NewLayeredCacheProvider(local, NewRedisCacheProvider(redisClient), time.Minute)
+	ctx := context.Background()
+
+	var s TestStruct
+	if err := cacheProvider.GetUnmarshalled(ctx, "some key", &s); err != nil {
+		t.Fatalf("Value in remote cache should be retrieved")
+	}
+
+	now = now.Add(20 * time.Second)
+
+	if err := local.GetUnmarshalled(ctx, "some key", &s); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("Back-filled local entry should not outlive the remote one")
+	}
+}
+
+func TestLayeredCache_ReadsLocalFirst(t *testing.T) {
+	local := NewMemoryCacheProvider(10, 1024)
+	remote := NewRedisCacheProvider(MockRedisCacheClient(``, errors.New("redis is down"), nil))
+	cacheProvider := NewLayeredCacheProvider(local, remote, time.Minute)
+	ctx := context.Background()
+
+	_ = local.SetMarshalled(ctx, "some key", TestStruct{Key: "local"}, time.Minute)
+
+	var s TestStruct
+	if err := cacheProvider.GetUnmarshalled(ctx, "some key", &s); err != nil || s.Key != "local" {
+		t.Fatalf("Value in local cache should be retrieved without reaching remote cache")
+	}
+
+	if err := cacheProvider.GetUnmarshalled(ctx, "other key", &s); err == nil {
+		t.Fatalf("Missing value in both tiers should return an error")
+	}
+}
+
+func TestLayeredCache_WritesThrough(t *testing.T) {
+	fakeCache := make(map[string]interface{})
+	local := NewMemoryCacheProvider(10, 1024)
+	now := time.Now()
+	local.(*memoryCacheProvider).now = func() time.Time { return now }
+	remote := NewRedisCacheProvider(MockRedisCacheClient(``, nil, fakeCache))
+	cacheProvider := NewLayeredCacheProvider(local, remote, time.Minute)
+	ctx := context.Background()
+
+	if err := cacheProvider.SetMarshalled(ctx, "some key", TestStruct{Key: "somefield"}, time.Hour); err != nil {
+		t.Fatalf("Setting up a valid marshallable value in cache should not return an error")
+	}
+
+	if _, ok := fakeCache["some key"]; !ok {
+		t.Fatalf("Value should have been written in remote cache")
+	}
+
+	var s TestStruct
+	if err := local.GetUnmarshalled(ctx, "some key", &s); err != nil {
+		t.Fatalf("Value should have been written in local cache")
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	if err := local.GetUnmarshalled(ctx, "some key", &s); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("Local entry should expire with the local TTL instead of the remote one")
+	}
+}
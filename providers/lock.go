@@ -0,0 +1,61 @@
+package providers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+)
+
+// Allow to perform distributed locking, eg to coalesce work accross replicas
+type LockProvider interface {
+	// Tries to acquire the lock identified by key for at most expiresIn, acquired is false if the lock is already held.
+	// unlock must be called to release an acquired lock
+	TryLock(ctx context.Context, key string, expiresIn time.Duration) (unlock func(), acquired bool, err error)
+}
+
+// Releases a lock only if it is still held by the given token, so that an expired lock acquired by someone else is not released
+const redisUnlockScript = `if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`
+
+// IoC of the Redis client used for locking
+type RedisLockClient struct {
+	SetNX func(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
+	Eval  func(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd
+}
+
+type redisLockProvider struct {
+	client *RedisLockClient
+}
+
+func (r *redisLockProvider) TryLock(ctx context.Context, key string, expiresIn time.Duration) (func(), bool, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, false, err
+	}
+	value := hex.EncodeToString(token)
+	lockKey := "lock:" + key
+
+	acquired, err := r.client.SetNX(ctx, lockKey, value, expiresIn).Result()
+
+	if err != nil || !acquired {
+		return nil, false, err
+	}
+
+	unlock := func() {
+		// The lock must be released even if the request context is done
+		_ = r.client.Eval(context.Background(), redisUnlockScript, []string{lockKey}, value).Err()
+	}
+
+	return unlock, true, nil
+}
+
+func NewRedisLockProvider(client *RedisLockClient) LockProvider {
+	return &redisLockProvider{
+		client: &RedisLockClient{
+			SetNX: client.SetNX,
+			Eval:  client.Eval,
+		},
+	}
+}
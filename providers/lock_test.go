@@ -0,0 +1,67 @@
+package providers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Fake redis locking on a map
+func MockRedisLockClient(locks map[string]interface{}) *RedisLockClient {
+	return &RedisLockClient{
+		SetNX: func(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
+			cmd := &redis.BoolCmd{}
+			if _, ok := locks[key]; ok {
+				cmd.SetVal(false)
+			} else {
+				locks[key] = value
+				cmd.SetVal(true)
+			}
+			return cmd
+		},
+		Eval: func(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+			cmd := &redis.Cmd{}
+			if locks[keys[0]] == args[0] {
+				delete(locks, keys[0])
+				cmd.SetVal(int64(1))
+			} else {
+				cmd.SetVal(int64(0))
+			}
+			return cmd
+		},
+	}
+}
+
+func TestRedisLock(t *testing.T) {
+	locks := make(map[string]interface{})
+	lockProvider := NewRedisLockProvider(MockRedisLockClient(locks))
+	ctx := context.Background()
+
+	unlock, acquired, err := lockProvider.TryLock(ctx, "some key", time.Minute)
+
+	if err != nil || !acquired {
+		t.Fatalf("Free lock should be acquired")
+	}
+
+	if _, acquired, _ := lockProvider.TryLock(ctx, "some key", time.Minute); acquired {
+		t.Fatalf("Held lock should not be acquired")
+	}
+
+	// Simulates the lock expiring and being acquired by another replica
+	locks["lock:some key"] = "another token"
+	unlock()
+
+	if _, ok := locks["lock:some key"]; !ok {
+		t.Fatalf("Lock held by another token should not be released")
+	}
+
+	delete(locks, "lock:some key")
+	unlock, acquired, _ = lockProvider.TryLock(ctx, "some key", time.Minute)
+	unlock()
+
+	if _, ok := locks["lock:some key"]; !acquired || ok {
+		t.Fatalf("Lock should be released by its holder")
+	}
+}
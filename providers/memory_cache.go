@@ -0,0 +1,153 @@
+package providers
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Returned by a CacheProvider when the key is not in cache or has expired
+var ErrCacheMiss = errors.New("cache miss")
+
+// Returned by a CacheProvider when a value is larger than the cache capacity
+var ErrCacheEntryTooLarge = errors.New("cache entry is too large")
+
+// Hit and miss counters of a cache
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+	Bytes     int
+}
+
+// CacheProvider storing values in process memory
+type MemoryCacheProvider interface {
+	CacheProvider
+	// Returns the current counters of the cache
+	Stats() CacheStats
+}
+
+type memoryCacheEntry struct {
+	key   string
+	value []byte
+	// Zero when the entry never expires
+	expiresAt time.Time
+}
+
+// In memory CacheProvider bounded by entry count and byte size, evicting the least recently used entries first
+type memoryCacheProvider struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	// Most recently used entries are at the front
+	lru     *list.List
+	entries map[string]*list.Element
+	stats   CacheStats
+	now     func() time.Time
+}
+
+func (m *memoryCacheProvider) GetUnmarshalled(ctx context.Context, key string, unmarshalledPayload any) error {
+	m.mu.Lock()
+	element, ok := m.entries[key]
+
+	if ok && m.isExpired(element.Value.(*memoryCacheEntry)) {
+		m.remove(element)
+		ok = false
+	}
+
+	if !ok {
+		m.stats.Misses++
+		m.mu.Unlock()
+		return ErrCacheMiss
+	}
+
+	m.stats.Hits++
+	m.lru.MoveToFront(element)
+	// Values are never mutated once set, they can safely be read outside of the lock
+	value := element.Value.(*memoryCacheEntry).value
+	m.mu.Unlock()
+
+	return json.Unmarshal(value, unmarshalledPayload)
+}
+
+func (m *memoryCacheProvider) SetMarshalled(ctx context.Context, key string, value any, expiresIn time.Duration) error {
+	marshalled, err := json.Marshal(value)
+
+	if err != nil {
+		return err
+	}
+
+	entry := &memoryCacheEntry{
+		key:   key,
+		value: marshalled,
+	}
+
+	if entry.size() > m.maxBytes {
+		return ErrCacheEntryTooLarge
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Same semantic as redis, a zero expiration means that the entry never expires
+	if expiresIn > 0 {
+		entry.expiresAt = m.now().Add(expiresIn)
+	}
+
+	if element, ok := m.entries[key]; ok {
+		m.remove(element)
+	}
+
+	m.entries[key] = m.lru.PushFront(entry)
+	m.bytes += entry.size()
+
+	for len(m.entries) > m.maxEntries || m.bytes > m.maxBytes {
+		m.remove(m.lru.Back())
+		m.stats.Evictions++
+	}
+
+	return nil
+}
+
+func (m *memoryCacheProvider) Stats() CacheStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	stats.Entries = len(m.entries)
+	stats.Bytes = m.bytes
+
+	return stats
+}
+
+// Must be called with mu held
+func (m *memoryCacheProvider) isExpired(entry *memoryCacheEntry) bool {
+	return !entry.expiresAt.IsZero() && !m.now().Before(entry.expiresAt)
+}
+
+// Must be called with mu held
+func (m *memoryCacheProvider) remove(element *list.Element) {
+	entry := m.lru.Remove(element).(*memoryCacheEntry)
+	delete(m.entries, entry.key)
+	m.bytes -= entry.size()
+}
+
+func (e *memoryCacheEntry) size() int {
+	return len(e.key) + len(e.value)
+}
+
+// Creates an in memory CacheProvider holding at most maxEntries entries and maxBytes bytes of keys and marshalled values, both must be greater than 0
+func NewMemoryCacheProvider(maxEntries, maxBytes int) MemoryCacheProvider {
+	return &memoryCacheProvider{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
@@ -0,0 +1,102 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache_SetAndGet(t *testing.T) {
+	cacheProvider := NewMemoryCacheProvider(10, 1024)
+	ctx := context.Background()
+
+	expected := TestStruct{Key: "somefield", Value: 1}
+	if err := cacheProvider.SetMarshalled(ctx, "some key", expected, time.Hour); err != nil {
+		t.Fatalf("Setting up a valid marshallable value in cache should not return an error")
+	}
+
+	var s TestStruct
+	if err := cacheProvider.GetUnmarshalled(ctx, "some key", &s); err != nil {
+		t.Fatalf("Cached value should be retrieved")
+	}
+
+	if !reflect.DeepEqual(s, expected) {
+		t.Fatalf("Unmarshalled value from memory cache should equals to expected")
+	}
+
+	if err := cacheProvider.GetUnmarshalled(ctx, "other key", &s); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("Missing key should return ErrCacheMiss")
+	}
+
+	stats := cacheProvider.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Fatalf("Cache stats should count hits and misses, got %+v", stats)
+	}
+}
+
+func TestMemoryCache_Expiration(t *testing.T) {
+	cacheProvider := NewMemoryCacheProvider(10, 1024)
+	now := time.Now()
+	cacheProvider.(*memoryCacheProvider).now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_ = cacheProvider.SetMarshalled(ctx, "expiring", TestStruct{}, time.Minute)
+	_ = cacheProvider.SetMarshalled(ctx, "persistent", TestStruct{}, 0)
+
+	now = now.Add(2 * time.Minute)
+
+	var s TestStruct
+	if err := cacheProvider.GetUnmarshalled(ctx, "expiring", &s); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("Expired entry should return ErrCacheMiss")
+	}
+
+	if err := cacheProvider.GetUnmarshalled(ctx, "persistent", &s); err != nil {
+		t.Fatalf("Entry without expiration should never expire")
+	}
+}
+
+func TestMemoryCache_LRUEviction(t *testing.T) {
+	cacheProvider := NewMemoryCacheProvider(2, 1024)
+	ctx := context.Background()
+
+	_ = cacheProvider.SetMarshalled(ctx, "a", TestStruct{}, time.Hour)
+	_ = cacheProvider.SetMarshalled(ctx, "b", TestStruct{}, time.Hour)
+
+	// Using "a" makes "b" the least recently used entry
+	var s TestStruct
+	_ = cacheProvider.GetUnmarshalled(ctx, "a", &s)
+	_ = cacheProvider.SetMarshalled(ctx, "c", TestStruct{}, time.Hour)
+
+	if err := cacheProvider.GetUnmarshalled(ctx, "b", &s); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("Least recently used entry should have been evicted")
+	}
+
+	if err := cacheProvider.GetUnmarshalled(ctx, "a", &s); err != nil {
+		t.Fatalf("Recently used entry should not have been evicted")
+	}
+
+	if stats := cacheProvider.Stats(); stats.Evictions != 1 || stats.Entries != 2 {
+		t.Fatalf("Cache stats should count evictions, got %+v", stats)
+	}
+}
+
+func TestMemoryCache_MaxBytes(t *testing.T) {
+	// {"key":"","value":0} is 20 bytes long, plus a 1 byte key
+	cacheProvider := NewMemoryCacheProvider(10, 50)
+	ctx := context.Background()
+
+	_ = cacheProvider.SetMarshalled(ctx, "a", TestStruct{}, time.Hour)
+	_ = cacheProvider.SetMarshalled(ctx, "b", TestStruct{}, time.Hour)
+	_ = cacheProvider.SetMarshalled(ctx, "c", TestStruct{}, time.Hour)
+
+	if stats := cacheProvider.Stats(); stats.Entries != 2 || stats.Bytes != 42 {
+		t.Fatalf("Cache should be bounded by its byte size, got %+v", stats)
+	}
+
+	err := cacheProvider.SetMarshalled(ctx, "too large", TestStruct{Key: "a very long value that exceeds the cache capacity"}, time.Hour)
+	if !errors.Is(err, ErrCacheEntryTooLarge) {
+		t.Fatalf("Entry larger than the cache should not be stored")
+	}
+}
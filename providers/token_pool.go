@@ -0,0 +1,136 @@
+package providers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Allow to pick the token used to authenticate requests to a rate limited API
+type TokenProvider interface {
+	// Returns the token to use for a request on the given rate limit resource, empty string if the request should be unauthenticated
+	Token(resource string) string
+	// Updates the rate limit state of a token
+	Update(token string, rateLimit RateLimit)
+}
+
+// Rate limit state of a token for a given resource
+type tokenState struct {
+	// -1 when the state is unknown, ie the token has not been used yet or its window has been reset
+	remaining int
+	reset     time.Time
+}
+
+// TokenProvider rotating accross many tokens, picks the token with the most remaining quota and parks exhausted tokens until their reset time
+type tokenPool struct {
+	mu     sync.Mutex
+	tokens []string
+	// Rate limit states indexed by resource then by token
+	states map[string]map[string]*tokenState
+	now    func() time.Time
+}
+
+func (tp *tokenPool) Token(resource string) string {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	now := tp.now()
+	bestToken := ""
+	bestRemaining := 0
+
+	// Tokens are iterated in configuration order so that ties are deterministic
+	for _, token := range tp.tokens {
+		remaining := tp.stateOf(resource, token, now).remaining
+
+		// Unknown states are preferred as they are expected to have a full quota
+		if remaining == -1 {
+			remaining = math.MaxInt
+		}
+
+		if remaining > bestRemaining {
+			bestToken = token
+			bestRemaining = remaining
+		}
+	}
+
+	// Falls back to unauthenticated mode when every token is exhausted
+	return bestToken
+}
+
+func (tp *tokenPool) Update(token string, rateLimit RateLimit) {
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	state := tp.stateOf(rateLimit.Resource, token, tp.now())
+	state.remaining = rateLimit.Remaining
+	state.reset = rateLimit.Reset
+}
+
+// Returns the state of a token for a resource, resetting it if its window is over. Must be called with mu held
+func (tp *tokenPool) stateOf(resource, token string, now time.Time) *tokenState {
+	if _, ok := tp.states[resource]; !ok {
+		tp.states[resource] = make(map[string]*tokenState)
+	}
+
+	state, ok := tp.states[resource][token]
+	if !ok {
+		state = &tokenState{remaining: -1}
+		tp.states[resource][token] = state
+	}
+
+	if state.remaining != -1 && !now.Before(state.reset) {
+		state.remaining = -1
+	}
+
+	return state
+}
+
+// Creates a TokenProvider rotating accross the given tokens, empty tokens are ignored
+func NewTokenPool(tokens []string) TokenProvider {
+	filtered := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if token = strings.TrimSpace(token); token != "" {
+			filtered = append(filtered, token)
+		}
+	}
+
+	return &tokenPool{
+		tokens: filtered,
+		states: make(map[string]map[string]*tokenState),
+		now:    time.Now,
+	}
+}
+
+// Wraps a NativeHttpClient so that the rate limit state of each response updates the token used by its request
+func NewTokenObservingHttpClient(client NativeHttpClient, tokenProvider TokenProvider) NativeHttpClient {
+	return NativeHttpClient{
+		Do: func(req *http.Request) (*http.Response, error) {
+			response, err := client.Do(req)
+
+			if err != nil {
+				return response, err
+			}
+
+			token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+
+			var rateLimitErr *RateLimitError
+			if errors.As(statusError(response), &rateLimitErr) {
+				// Secondary rate limits do not exhaust the quota but the token must still be parked until Retry-After
+				rateLimit := rateLimitErr.RateLimit
+				if rateLimit.Resource == "" {
+					rateLimit.Resource = RATE_LIMIT_RESOURCE_CORE
+				}
+				rateLimit.Remaining = 0
+				rateLimit.Reset = time.Now().Add(rateLimitErr.RetryAfter)
+				tokenProvider.Update(token, rateLimit)
+			} else if rateLimit, ok := RateLimitFromHeader(response.Header); ok {
+				tokenProvider.Update(token, rateLimit)
+			}
+
+			return response, nil
+		},
+	}
+}
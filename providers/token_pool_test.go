@@ -0,0 +1,89 @@
+package providers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTokenPool_PicksMostRemaining(t *testing.T) {
+	pool := NewTokenPool([]string{"a", "b", "c"})
+	reset := time.Now().Add(time.Hour)
+
+	pool.Update("a", RateLimit{Resource: RATE_LIMIT_RESOURCE_CORE, Remaining: 10, Reset: reset})
+	pool.Update("b", RateLimit{Resource: RATE_LIMIT_RESOURCE_CORE, Remaining: 4000, Reset: reset})
+	pool.Update("c", RateLimit{Resource: RATE_LIMIT_RESOURCE_CORE, Remaining: 200, Reset: reset})
+
+	if token := pool.Token(RATE_LIMIT_RESOURCE_CORE); token != "b" {
+		t.Fatalf("Should have picked the token with the most remaining quota, got %s", token)
+	}
+
+	// Resources are rate limited independently
+	pool.Update("a", RateLimit{Resource: RATE_LIMIT_RESOURCE_SEARCH, Remaining: 0, Reset: reset})
+	if token := pool.Token(RATE_LIMIT_RESOURCE_SEARCH); token != "b" {
+		t.Fatalf("Should have picked the first unused token for the search resource, got %s", token)
+	}
+}
+
+func TestTokenPool_ParksExhaustedTokens(t *testing.T) {
+	pool := NewTokenPool([]string{"a", "b"})
+	now := time.Now()
+	pool.(*tokenPool).now = func() time.Time { return now }
+
+	pool.Update("a", RateLimit{Resource: RATE_LIMIT_RESOURCE_CORE, Remaining: 0, Reset: now.Add(time.Minute)})
+	pool.Update("b", RateLimit{Resource: RATE_LIMIT_RESOURCE_CORE, Remaining: 0, Reset: now.Add(time.Hour)})
+
+	if token := pool.Token(RATE_LIMIT_RESOURCE_CORE); token != "" {
+		t.Fatalf("Should have fallen back to unauthenticated mode when every token is exhausted, got %s", token)
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	if token := pool.Token(RATE_LIMIT_RESOURCE_CORE); token != "a" {
+		t.Fatalf("Should have unparked the token after its reset time, got %s", token)
+	}
+}
+
+func TestTokenPool_NoTokens(t *testing.T) {
+	pool := NewTokenPool([]string{"", " "})
+
+	if token := pool.Token(RATE_LIMIT_RESOURCE_CORE); token != "" {
+		t.Fatalf("Should be unauthenticated without tokens, got %s", token)
+	}
+}
+
+func TestTokenObservingHttpClient(t *testing.T) {
+	pool := NewTokenPool([]string{"a", "b"})
+	remaining := map[string]string{"Bearer a": "0", "Bearer b": "42"}
+
+	client := NewTokenObservingHttpClient(NativeHttpClient{
+		Do: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header: http.Header{
+					"X-Ratelimit-Remaining": {remaining[req.Header.Get("Authorization")]},
+					"X-Ratelimit-Reset":     {fmt.Sprintf("%d", time.Now().Add(time.Hour).Unix())},
+					"X-Ratelimit-Resource":  {RATE_LIMIT_RESOURCE_SEARCH},
+				},
+				Body: io.NopCloser(bytes.NewReader([]byte{})),
+			}, nil
+		},
+	}, pool)
+
+	for _, token := range []string{"a", "b"} {
+		req, _ := http.NewRequest(http.MethodGet, "https://somedataendpoint.io", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		_, _ = client.Do(req)
+	}
+
+	if token := pool.Token(RATE_LIMIT_RESOURCE_SEARCH); token != "b" {
+		t.Fatalf("Should have updated the pool from the response headers, got %s", token)
+	}
+
+	if token := pool.Token(RATE_LIMIT_RESOURCE_CORE); token != "a" {
+		t.Fatalf("Other resources should not have been updated, got %s", token)
+	}
+}
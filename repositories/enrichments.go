@@ -0,0 +1,330 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/builder"
+	"github.com/LasramR/sclng-backend-test-lasramR/model"
+	"github.com/LasramR/sclng-backend-test-lasramR/model/external"
+	"github.com/LasramR/sclng-backend-test-lasramR/providers"
+	"github.com/LasramR/sclng-backend-test-lasramR/util"
+)
+
+// Base url of the GitHub REST API, enrichments are fetched from it whatever the API used to search repositories
+const GITHUB_REST_API_URL = "https://api.github.com"
+
+// Enrichments of the expand parameter
+const (
+	CONTRIBUTORS_ENRICHMENT = "contributors"
+	ACTIVITY_ENRICHMENT     = "activity"
+	RELEASES_ENRICHMENT     = "releases"
+)
+
+// Every enrichment, none of them is fetched unless expanded
+var enrichments = []string{CONTRIBUTORS_ENRICHMENT, ACTIVITY_ENRICHMENT, RELEASES_ENRICHMENT}
+
+// Number of top contributors fetched per repository
+const contributorsLimit = 10
+
+// Delays between the attempts of a request responded with a 202 while Github computes statistics, the enrichment is pending once they are exhausted
+var pendingRetryDelays = []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+
+// Fetches the enrichments of repositories from the GitHub REST API, each of them is cached per repository
+type repositoryEnricher struct {
+	apiBaseUrl         string
+	tokenProvider      providers.TokenProvider
+	httpProvider       providers.HttpProvider
+	cacheProvider      providers.CacheProvider
+	cacheDurationInMin time.Duration
+	// Releases change less often than repositories, they are cached longer
+	releasesCacheDurationInMin time.Duration
+	// Shared by every enrichment so that concurrent enrichments of the same repository fetch it once
+	coalescer *util.Coalescer[json.RawMessage]
+}
+
+// Repositories enriched through util.AsyncListMapper
+type enrichedRepositories []*model.Repository
+
+func (r enrichedRepositories) Items() []*model.Repository {
+	return r
+}
+
+func (r enrichedRepositories) Count() int {
+	return len(r)
+}
+
+// Returned when an enrichment or a field of a repository could not be fetched, the field is null in the partially mapped repository
+type EnrichmentError struct {
+	Enrichment string
+	Err        error
+}
+
+func (e *EnrichmentError) Error() string {
+	return fmt.Sprintf("%s enrichment failed: %s", e.Enrichment, e.Err)
+}
+
+func (e *EnrichmentError) Unwrap() error {
+	return e.Err
+}
+
+// Fetches the enrichments of the selection into repository, failed enrichments are null and their *EnrichmentError are joined in the returned error
+func (re *repositoryEnricher) enrich(ctx context.Context, repository *model.Repository, selection []string) error {
+	repositoryPath := fmt.Sprintf("/repos/%s/%s", url.PathEscape(repository.Owner), url.PathEscape(repository.Repository))
+	var contributorsErr, activityErr, releasesErr error
+
+	if slices.Contains(selection, CONTRIBUTORS_ENRICHMENT) {
+		repository.Contributors, contributorsErr = fetchEnrichment(CONTRIBUTORS_ENRICHMENT, func() (model.Contributors, error) {
+			return re.contributors(ctx, repositoryPath)
+		})
+	}
+
+	if slices.Contains(selection, ACTIVITY_ENRICHMENT) {
+		repository.Activity, activityErr = fetchEnrichment(ACTIVITY_ENRICHMENT, func() (model.Activity, error) {
+			return re.activity(ctx, repositoryPath)
+		})
+	}
+
+	if slices.Contains(selection, RELEASES_ENRICHMENT) {
+		repository.Releases, releasesErr = fetchEnrichment(RELEASES_ENRICHMENT, func() (model.Releases, error) {
+			return re.releases(ctx, repositoryPath)
+		})
+	}
+
+	return errors.Join(contributorsErr, activityErr, releasesErr)
+}
+
+// Fetches the top contributors of the repository at repositoryPath
+func (re *repositoryEnricher) contributors(ctx context.Context, repositoryPath string) (model.Contributors, error) {
+	rawContributors, pending, err := reqEnrichment[[]external.Contributor](ctx, re, fmt.Sprintf("%s/contributors?per_page=%d", repositoryPath, contributorsLimit), time.Minute*re.cacheDurationInMin)
+
+	if err != nil {
+		return model.Contributors{}, err
+	}
+
+	contributors := model.Contributors{
+		Pending: pending,
+		Top:     make([]model.Contributor, 0, len(rawContributors)),
+	}
+	for _, contributor := range rawContributors {
+		contributors.Top = append(contributors.Top, model.Contributor{
+			Login:         contributor.Login,
+			Contributions: contributor.Contributions,
+		})
+	}
+
+	return contributors, nil
+}
+
+// Fetches the weekly commit activity of the repository at repositoryPath
+func (re *repositoryEnricher) activity(ctx context.Context, repositoryPath string) (model.Activity, error) {
+	weeks, pending, err := reqEnrichment[[]external.CommitActivityWeek](ctx, re, repositoryPath+"/stats/commit_activity", time.Minute*re.cacheDurationInMin)
+
+	if err != nil {
+		return model.Activity{}, err
+	}
+
+	activity := model.Activity{
+		Pending: pending,
+		Weeks:   make([]model.WeeklyActivity, 0, len(weeks)),
+	}
+	for _, week := range weeks {
+		activity.TotalCommits += week.Total
+		activity.Weeks = append(activity.Weeks, model.WeeklyActivity{
+			Week:    time.Unix(week.Week, 0).UTC(),
+			Commits: week.Total,
+		})
+	}
+
+	return activity, nil
+}
+
+// Fetches the latest release of the repository at repositoryPath, falling back to its most recent tag.
+// A repository without release nor tag is not a failure, its latest release is null
+func (re *repositoryEnricher) releases(ctx context.Context, repositoryPath string) (model.Releases, error) {
+	freshFor := time.Minute * re.releasesCacheDurationInMin
+	// The outcome is cached as a whole so that repositories without releases are not requested again
+	cacheKey := fmt.Sprintf("%s%s/releases#latest", re.apiBaseUrl, repositoryPath)
+
+	var releases model.Releases
+	if err := re.cacheProvider.GetUnmarshalled(ctx, cacheKey, &releases); err == nil {
+		return releases, nil
+	}
+
+	release, _, err := reqEnrichment[external.Release](ctx, re, repositoryPath+"/releases/latest", freshFor)
+
+	switch {
+	case err == nil:
+		releases.Latest = util.NullableJsonField[model.Release]{
+			Value: model.Release{
+				Version:     release.TagName,
+				PublishedAt: release.PublishedAt,
+				Prerelease:  release.Prerelease,
+			},
+		}
+	// Github responds with a 404 when the repository has no release
+	case errors.Is(err, providers.ErrNotFound):
+		tags, _, err := reqEnrichment[[]external.Tag](ctx, re, repositoryPath+"/tags?per_page=1", freshFor)
+
+		if err != nil && !errors.Is(err, providers.ErrNotFound) {
+			return model.Releases{}, err
+		}
+
+		releases.Latest = util.NullableJsonField[model.Release]{IsNull: len(tags) == 0}
+		if len(tags) != 0 {
+			releases.Latest.Value = model.Release{
+				Version:     tags[0].Name,
+				PublishedAt: util.NullableJsonField[time.Time]{IsNull: true},
+			}
+		}
+	default:
+		return model.Releases{}, err
+	}
+
+	_ = re.cacheProvider.SetMarshalled(ctx, cacheKey, releases, freshFor)
+
+	return releases, nil
+}
+
+// Returns copies of repositories holding the enrichments requested by grb along with the failures of their enrichments
+func (re *repositoryEnricher) enrichAll(ctx context.Context, grb builder.GithubRequestBuilder, repositories []*model.Repository) ([]*model.Repository, []model.RepositoryError) {
+	selection := grb.Selection()
+	if !slices.ContainsFunc(selection, func(field string) bool { return slices.Contains(enrichments, field) }) {
+		return repositories, nil
+	}
+
+	return enrichCopies(ctx, repositories, func(ctx context.Context, repository *model.Repository) error {
+		return re.enrich(ctx, repository, selection)
+	})
+}
+
+// Returns copies of repositories enriched concurrently by enrich along with the failures of their enrichments
+func enrichCopies(ctx context.Context, repositories []*model.Repository, enrich func(ctx context.Context, repository *model.Repository) error) ([]*model.Repository, []model.RepositoryError) {
+	enriched, errorsCollected := util.AsyncListMapper(
+		ctx,
+		enrichedRepositories(repositories),
+		func(ctx context.Context, repository *model.Repository) (*model.Repository, error) {
+			if repository == nil {
+				return nil, nil
+			}
+
+			copied := *repository
+			return &copied, enrich(ctx, &copied)
+		},
+		time.Second*30,
+	)
+
+	return enriched, repositoryErrors(enriched, errorsCollected)
+}
+
+// Fetches an enrichment with fetch, the returned field is null if it failed
+func fetchEnrichment[T any](enrichment string, fetch func() (T, error)) (*util.NullableJsonField[T], error) {
+	value, err := fetch()
+
+	if err != nil {
+		return &util.NullableJsonField[T]{IsNull: true}, &EnrichmentError{Enrichment: enrichment, Err: err}
+	}
+
+	return &util.NullableJsonField[T]{Value: value}, nil
+}
+
+// Converts the error returned along with a partially fetched repository, see GithubApiRepository.GetRepository
+func RepositoryErrorsOf(repository *model.Repository, err error) []model.RepositoryError {
+	return repositoryErrors([]*model.Repository{repository}, []util.IndexedError{{Index: 0, Error: err}})
+}
+
+// Converts the errors collected while mapping repositories, joined errors are reported one by one
+func repositoryErrors(repositories []*model.Repository, errorsCollected []util.IndexedError) []model.RepositoryError {
+	repositoryErrs := make([]model.RepositoryError, 0, len(errorsCollected))
+
+	for _, indexed := range errorsCollected {
+		var fullName string
+		if repository := repositories[indexed.Index]; repository != nil {
+			fullName = repository.FullName
+		}
+
+		failures := []error{indexed.Error}
+		if joined, ok := indexed.Error.(interface{ Unwrap() []error }); ok {
+			failures = joined.Unwrap()
+		}
+
+		for _, failure := range failures {
+			repositoryErr := model.RepositoryError{
+				Index:    indexed.Index,
+				FullName: fullName,
+				Reason:   failure.Error(),
+			}
+
+			var enrichmentErr *EnrichmentError
+			if errors.As(failure, &enrichmentErr) {
+				repositoryErr.Enrichment = enrichmentErr.Enrichment
+				repositoryErr.Reason = enrichmentErr.Err.Error()
+			}
+
+			repositoryErrs = append(repositoryErrs, repositoryErr)
+		}
+	}
+
+	return repositoryErrs
+}
+
+// Fetches the enrichment at path through the cache where it is fresh for freshFor, a 202 response is retried after each of pendingRetryDelays.
+// pending is true if Github is still computing the enrichment once they are exhausted, a 204 response is an empty enrichment
+func reqEnrichment[T any](ctx context.Context, re *repositoryEnricher, path string, freshFor time.Duration) (enrichment T, pending bool, err error) {
+	enrichmentUrl := re.apiBaseUrl + path
+
+	for attempt := 0; ; attempt++ {
+		raw, err, _ := re.coalescer.Do(ctx, enrichmentUrl, func() (json.RawMessage, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, enrichmentUrl, nil)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if githubToken := re.tokenProvider.Token(providers.RATE_LIMIT_RESOURCE_CORE); githubToken != "" {
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", githubToken))
+			}
+
+			// Pending enrichments are not cached as ReqCachedConditional only caches successful responses
+			return providers.ReqCachedConditional[json.RawMessage](ctx, re.httpProvider, re.cacheProvider, req, freshFor, revalidationDuration)
+		})
+
+		if errors.Is(err, providers.ErrAccepted) {
+			if attempt == len(pendingRetryDelays) {
+				return enrichment, true, nil
+			}
+
+			select {
+			case <-time.After(pendingRetryDelays[attempt]):
+				continue
+			case <-ctx.Done():
+				return enrichment, true, nil
+			}
+		}
+
+		if err != nil || len(raw) == 0 {
+			return enrichment, false, err
+		}
+
+		return enrichment, false, json.Unmarshal(raw, &enrichment)
+	}
+}
+
+// Creates a repositoryEnricher fetching enrichments from the GitHub REST API
+func newRepositoryEnricher(httpProvider providers.HttpProvider, cacheProvider providers.CacheProvider, cacheDurationInMin, releasesCacheDurationInMin time.Duration, tokenProvider providers.TokenProvider) *repositoryEnricher {
+	return &repositoryEnricher{
+		apiBaseUrl:                 GITHUB_REST_API_URL,
+		tokenProvider:              tokenProvider,
+		httpProvider:               httpProvider,
+		cacheProvider:              cacheProvider,
+		cacheDurationInMin:         cacheDurationInMin,
+		releasesCacheDurationInMin: releasesCacheDurationInMin,
+		coalescer:                  util.NewCoalescer[json.RawMessage](),
+	}
+}
@@ -0,0 +1,297 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/builder"
+	"github.com/LasramR/sclng-backend-test-lasramR/model"
+	"github.com/LasramR/sclng-backend-test-lasramR/model/version"
+	"github.com/LasramR/sclng-backend-test-lasramR/providers"
+	"github.com/LasramR/sclng-backend-test-lasramR/util"
+)
+
+const GITHUB_CONTRIBUTORS_RESPONSE_BODY_SAMPLE = `[
+	{"login": "fmuiin14", "id": 1, "contributions": 42},
+	{"login": "octocat", "id": 2, "contributions": 3}
+]`
+
+const GITHUB_LATEST_RELEASE_RESPONSE_BODY_SAMPLE = `{
+	"tag_name": "v1.4.0",
+	"name": "BlazingTool 1.4",
+	"draft": false,
+	"prerelease": false,
+	"published_at": "2024-10-20T16:36:13Z"
+}`
+
+const GITHUB_TAGS_RESPONSE_BODY_SAMPLE = `[
+	{"name": "v0.9.0-beta", "commit": {"sha": "c5b97d5ae6c19d5c5df71a34c7fbeeda2479ccbc"}}
+]`
+
+const GITHUB_COMMIT_ACTIVITY_RESPONSE_BODY_SAMPLE = `[
+	{"days": [0, 1, 0, 2, 0, 0, 0], "total": 3, "week": 1727568000},
+	{"days": [0, 0, 4, 0, 1, 0, 0], "total": 5, "week": 1728172800}
+]`
+
+// Fake Github REST API responding to each path with the statuses of statusesPerPath in turn, then with the last one
+func MockEnrichmentsHttpProvider(statusesPerPath map[string][]int) providers.HttpProvider {
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	bodys := map[string]string{
+		"contributors":    GITHUB_CONTRIBUTORS_RESPONSE_BODY_SAMPLE,
+		"commit_activity": GITHUB_COMMIT_ACTIVITY_RESPONSE_BODY_SAMPLE,
+		"repositories":    GITHUB_SEARCH_REPOS_RESPONSE_BODY_SAMPLE,
+		"latest":          GITHUB_LATEST_RELEASE_RESPONSE_BODY_SAMPLE,
+		"tags":            GITHUB_TAGS_RESPONSE_BODY_SAMPLE,
+		"languages":       GITHUB_LANGUAGE_RESPONSE_BODY_SAMPLE_1,
+	}
+
+	return providers.NewNativeHttpProvider(providers.NativeHttpClient{
+		Do: func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			path := req.URL.Path
+			statuses := statusesPerPath[path]
+			status := http.StatusOK
+			if len(statuses) != 0 {
+				status = statuses[min(attempts[path], len(statuses)-1)]
+			}
+			attempts[path]++
+
+			body := "{}"
+			if status == http.StatusOK {
+				body = bodys[path[strings.LastIndex(path, "/")+1:]]
+			}
+
+			return &http.Response{
+				StatusCode: status,
+				Body:       io.NopCloser(bytes.NewReader([]byte(body))),
+			}, nil
+		},
+	})
+}
+
+func withPendingRetryDelays(t *testing.T, delays []time.Duration) {
+	previous := pendingRetryDelays
+	pendingRetryDelays = delays
+	t.Cleanup(func() {
+		pendingRetryDelays = previous
+	})
+}
+
+func TestEnrich_RetriesWhileComputing(t *testing.T) {
+	withPendingRetryDelays(t, []time.Duration{time.Millisecond, time.Millisecond})
+
+	enricher := newRepositoryEnricher(
+		MockEnrichmentsHttpProvider(map[string][]int{
+			"/repos/fmuiin14/BlazingTool/stats/commit_activity": {http.StatusAccepted, http.StatusOK},
+		}),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	repository := &model.Repository{Owner: "fmuiin14", Repository: "BlazingTool"}
+	err := enricher.enrich(context.Background(), repository, []string{CONTRIBUTORS_ENRICHMENT, ACTIVITY_ENRICHMENT})
+
+	if err != nil {
+		t.Fatalf("Should not have returned an error, got %s", err)
+	}
+
+	expectedContributors := &util.NullableJsonField[model.Contributors]{Value: model.Contributors{
+		Top: []model.Contributor{{Login: "fmuiin14", Contributions: 42}, {Login: "octocat", Contributions: 3}},
+	}}
+	if !reflect.DeepEqual(repository.Contributors, expectedContributors) {
+		t.Fatalf("Should have mapped the contributors, got %v", repository.Contributors)
+	}
+
+	expectedActivity := &util.NullableJsonField[model.Activity]{Value: model.Activity{
+		TotalCommits: 8,
+		Weeks: []model.WeeklyActivity{
+			{Week: time.Date(2024, time.September, 29, 0, 0, 0, 0, time.UTC), Commits: 3},
+			{Week: time.Date(2024, time.October, 6, 0, 0, 0, 0, time.UTC), Commits: 5},
+		},
+	}}
+	if !reflect.DeepEqual(repository.Activity, expectedActivity) {
+		t.Fatalf("Should have retried the activity until it was computed, got %v", repository.Activity)
+	}
+}
+
+func TestEnrich_Pending(t *testing.T) {
+	withPendingRetryDelays(t, []time.Duration{time.Millisecond})
+
+	enricher := newRepositoryEnricher(
+		MockEnrichmentsHttpProvider(map[string][]int{
+			"/repos/fmuiin14/BlazingTool/stats/commit_activity": {http.StatusAccepted},
+		}),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	repository := &model.Repository{Owner: "fmuiin14", Repository: "BlazingTool"}
+	err := enricher.enrich(context.Background(), repository, []string{ACTIVITY_ENRICHMENT})
+
+	if err != nil || repository.Activity == nil || !repository.Activity.Value.Pending {
+		t.Fatalf("Should have marked the activity pending once retries are exhausted")
+	}
+
+	if repository.Contributors != nil {
+		t.Fatalf("Should not have fetched contributors when they are not expanded")
+	}
+}
+
+func TestEnrich_Releases(t *testing.T) {
+	cases := []struct {
+		statusesPerPath map[string][]int
+		expected        util.NullableJsonField[model.Release]
+	}{
+		{
+			map[string][]int{},
+			util.NullableJsonField[model.Release]{Value: model.Release{
+				Version:     "v1.4.0",
+				PublishedAt: util.NullableJsonField[time.Time]{Value: time.Date(2024, time.October, 20, 16, 36, 13, 0, time.UTC)},
+			}},
+		},
+		{
+			map[string][]int{"/repos/fmuiin14/BlazingTool/releases/latest": {http.StatusNotFound}},
+			util.NullableJsonField[model.Release]{Value: model.Release{
+				Version:     "v0.9.0-beta",
+				PublishedAt: util.NullableJsonField[time.Time]{IsNull: true},
+			}},
+		},
+		{
+			map[string][]int{"/repos/fmuiin14/BlazingTool/releases/latest": {http.StatusNotFound}, "/repos/fmuiin14/BlazingTool/tags": {http.StatusNotFound}},
+			util.NullableJsonField[model.Release]{IsNull: true},
+		},
+	}
+
+	for _, c := range cases {
+		enricher := newRepositoryEnricher(
+			MockEnrichmentsHttpProvider(c.statusesPerPath),
+			MochCacheProvider("", errors.New("no value in cache"), nil),
+			5,
+			60,
+			providers.NewTokenPool([]string{"sometoken"}),
+		)
+
+		repository := &model.Repository{Owner: "fmuiin14", Repository: "BlazingTool"}
+		err := enricher.enrich(context.Background(), repository, []string{RELEASES_ENRICHMENT})
+
+		if err != nil || repository.Releases == nil || !reflect.DeepEqual(repository.Releases.Value.Latest, c.expected) {
+			t.Fatalf("Should have enriched the latest release %v, got %v", c.expected, repository.Releases)
+		}
+	}
+}
+
+func TestEnrich_Fails(t *testing.T) {
+	enricher := newRepositoryEnricher(
+		MockEnrichmentsHttpProvider(map[string][]int{
+			"/repos/fmuiin14/BlazingTool/contributors": {http.StatusInternalServerError},
+		}),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	repository := &model.Repository{Owner: "fmuiin14", Repository: "BlazingTool"}
+	err := enricher.enrich(context.Background(), repository, []string{CONTRIBUTORS_ENRICHMENT, ACTIVITY_ENRICHMENT})
+
+	var enrichmentErr *EnrichmentError
+	var statusErr *providers.HttpStatusError
+	if !errors.As(err, &enrichmentErr) || enrichmentErr.Enrichment != CONTRIBUTORS_ENRICHMENT || !errors.As(err, &statusErr) {
+		t.Fatalf("Should have returned the error of the failed enrichment, got %v", err)
+	}
+
+	if repository.Contributors == nil || !repository.Contributors.IsNull || repository.Activity == nil || repository.Activity.IsNull {
+		t.Fatalf("Should have marked the failed enrichment null and kept the other ones")
+	}
+}
+
+func TestGetGithubProjectsWithStats_Expanded(t *testing.T) {
+	gr, _ := NewGithubApiRepository(
+		version.GITHUB_API_2022_11_28,
+		MockEnrichmentsHttpProvider(map[string][]int{
+			"/repos/fmuiin14/ShadowTool/contributors": {http.StatusInternalServerError},
+		}),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	_ = grb.Fields("full_name")
+	_ = grb.Expand("contributors")
+
+	result, err := gr.GetManyRepositories(context.Background(), grb)
+
+	if err != nil || len(result.Repositories) != 2 {
+		t.Fatalf("Should have mapped the repositories")
+	}
+
+	if result.Repositories[0].Contributors == nil || len(result.Repositories[0].Contributors.Value.Top) != 2 {
+		t.Fatalf("Should have enriched the repositories with their contributors")
+	}
+
+	if result.Repositories[1] == nil || result.Repositories[1].FullName != "fmuiin14/ShadowTool" || !result.Repositories[1].Contributors.IsNull {
+		t.Fatalf("Repository whose enrichment failed should be partially returned with a null enrichment")
+	}
+
+	expected := []model.RepositoryError{{
+		Index:      1,
+		FullName:   "fmuiin14/ShadowTool",
+		Enrichment: CONTRIBUTORS_ENRICHMENT,
+		Reason:     "api responded with unexpected status 500 Internal Server Error",
+	}}
+	if !result.IncompleteResult || !reflect.DeepEqual(result.Errors, expected) {
+		t.Fatalf("Should have reported the failed enrichment, got %v", result.Errors)
+	}
+}
+
+func TestEnrichRepositories(t *testing.T) {
+	gr, _ := NewGithubApiRepository(
+		version.GITHUB_API_2022_11_28,
+		MockEnrichmentsHttpProvider(map[string][]int{
+			"/repos/fmuiin14/ShadowTool/languages": {http.StatusInternalServerError},
+		}),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	_ = grb.Fields("full_name,languages")
+	_ = grb.Expand("contributors")
+
+	minimal := []*model.Repository{
+		{FullName: "fmuiin14/BlazingTool", Owner: "fmuiin14", Repository: "BlazingTool"},
+		{FullName: "fmuiin14/ShadowTool", Owner: "fmuiin14", Repository: "ShadowTool"},
+	}
+	enriched, repositoryErrs := gr.EnrichRepositories(context.Background(), grb, minimal)
+
+	if len(enriched) != 2 || enriched[0].Languages == nil || enriched[0].Contributors == nil || len(enriched[0].Contributors.Value.Top) != 2 {
+		t.Fatalf("Should have fetched the languages and enrichments of the repositories")
+	}
+
+	if minimal[0].Languages != nil || minimal[0].Contributors != nil {
+		t.Fatalf("Should have enriched copies of the repositories")
+	}
+
+	if enriched[1].Languages != nil || len(repositoryErrs) != 1 || repositoryErrs[0].Index != 1 || repositoryErrs[0].Enrichment != "languages" {
+		t.Fatalf("Should have reported the failed languages, got %v", repositoryErrs)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"slices"
 	"time"
 
 	"github.com/LasramR/sclng-backend-test-lasramR/builder"
@@ -22,40 +24,59 @@ type GithubRepositoriesResult struct {
 	Total int `json:"total"`
 	// Set to true if some sub aggregations failed
 	IncompleteResult bool `json:"incomplete_result"`
+	// Failures of the sub aggregations, failed fields are null in Repositories
+	Errors []model.RepositoryError `json:"errors,omitempty"`
+	// Opaque cursor of the next page of a deep pagination walk, empty at the end of the walk
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Page number and page size of a deep pagination walk, unset for page based requests
+	CursorPage  int `json:"cursor_page,omitempty"`
+	CursorLimit int `json:"cursor_limit,omitempty"`
 }
 
+// Duration during which an expired Github response is kept in cache to be revalidated with its ETag
+const revalidationDuration = time.Hour
+
+// Returned when the requested repository does not exist on Github
+var ErrRepositoryNotFound = errors.New("repository not found")
+
+// Returned by the GraphQL API repository when Github did not serve the requested page, see githubGraphQLApiRepository.GetManyRepositories
+var ErrPageNotServed = errors.New("page could not be served by the GraphQL API")
+
 // Allow to interact with the GitHub REST API
 type GithubApiRepository interface {
 	// Fetch many repositories, error != nil if
 	GetManyRepositories(ctx context.Context, grb builder.GithubRequestBuilder) (GithubRepositoriesResult, error)
+	// Fetch a single repository by its owner and name, error is ErrRepositoryNotFound if it does not exist.
+	// A repository whose fields failed is returned with these fields null, along with their *EnrichmentError joined in error
+	GetRepository(ctx context.Context, grb builder.GithubRequestBuilder, owner, name string) (*model.Repository, error)
+	// Fetch the current rate limit state of the Github API, this request does not count against the rate limit
+	GetRateLimit(ctx context.Context, grb builder.GithubRequestBuilder) (model.RateLimit, error)
+	// Returns copies of repositories fetched with another selection, eg grb.Minimal(), holding the languages and enrichments requested by grb
+	// along with the failures of these fields
+	EnrichRepositories(ctx context.Context, grb builder.GithubRequestBuilder, repositories []*model.Repository) ([]*model.Repository, []model.RepositoryError)
 }
 
 // Parametized implementation of the GitHub repository that abstracts the entity mapping process
 type githubVersionnedApiRepository[T any, M util.Mappable[T]] struct {
-	githubToken        string
-	httpProvider       providers.HttpProvider
-	cacheProvider      providers.CacheProvider
-	mapperFunc         util.MapperFunc[T, *model.Repository]
+	tokenProvider providers.TokenProvider
+	httpProvider  providers.HttpProvider
+	cacheProvider providers.CacheProvider
+	// Returns the function mapping items of the external model to the repositories fields requested by grb
+	mapperFunc func(grb builder.GithubRequestBuilder) util.MapperFunc[T, *model.Repository]
+	// Fetches the languages at languagesUrl and the enrichments of repository requested by selection, failed fields are null
+	enrichFunc         func(ctx context.Context, repository *model.Repository, languagesUrl string, selection []string) error
 	cacheDurationInMin time.Duration
 }
 
 func (gr *githubVersionnedApiRepository[T, M]) GetManyRepositories(ctx context.Context, grb builder.GithubRequestBuilder) (GithubRepositoriesResult, error) {
-	grb.Authorization(gr.githubToken)
+	grb.Authorization(gr.tokenProvider.Token(providers.RATE_LIMIT_RESOURCE_SEARCH))
 	req, err := grb.Build(ctx, http.MethodGet, "/search/repositories")
 
 	if err != nil {
-		return GithubRepositoriesResult{}, nil
-	}
-
-	requestUrl := req.URL.String()
-	var repositories GithubRepositoriesResult
-
-	if err = gr.cacheProvider.GetUnmarshalled(ctx, requestUrl, &repositories); err == nil {
-		return repositories, nil
+		return GithubRepositoriesResult{}, err
 	}
 
-	var apiResponse M
-	err = gr.httpProvider.ReqUnmarshalledBody(req, &apiResponse)
+	apiResponse, err := providers.ReqCachedConditional[M](ctx, gr.httpProvider, gr.cacheProvider, req, time.Minute*gr.cacheDurationInMin, revalidationDuration)
 
 	if err != nil {
 		return GithubRepositoriesResult{}, err
@@ -64,85 +85,183 @@ func (gr *githubVersionnedApiRepository[T, M]) GetManyRepositories(ctx context.C
 	mapped, errorsCollected := util.AsyncListMapper(
 		ctx,
 		apiResponse,
-		gr.mapperFunc,
+		gr.mapperFunc(grb),
 		time.Second*30,
 	)
 
-	// If we had some results, we cache it
-	if len(errorsCollected) != len(apiResponse.Items()) {
-		_ = gr.cacheProvider.SetMarshalled(ctx, requestUrl, repositories, time.Minute*gr.cacheDurationInMin)
-	}
-
 	return GithubRepositoriesResult{
 		Repositories:     mapped,
 		Total:            apiResponse.Count(),
 		IncompleteResult: len(errorsCollected) != 0,
+		Errors:           repositoryErrors(mapped, errorsCollected),
+	}, nil
+}
+
+func (gr *githubVersionnedApiRepository[T, M]) GetRepository(ctx context.Context, grb builder.GithubRequestBuilder, owner, name string) (*model.Repository, error) {
+	grb.Authorization(gr.tokenProvider.Token(providers.RATE_LIMIT_RESOURCE_CORE))
+	req, err := grb.Build(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/%s", url.PathEscape(owner), url.PathEscape(name)))
+
+	if err != nil {
+		return nil, err
+	}
+
+	apiResponse, err := providers.ReqCachedConditional[T](ctx, gr.httpProvider, gr.cacheProvider, req, time.Minute*gr.cacheDurationInMin, revalidationDuration)
+
+	if errors.Is(err, providers.ErrNotFound) {
+		return nil, ErrRepositoryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Failed fields are null in the returned repository
+	return gr.mapperFunc(grb)(ctx, apiResponse)
+}
+
+func (gr *githubVersionnedApiRepository[T, M]) EnrichRepositories(ctx context.Context, grb builder.GithubRequestBuilder, repositories []*model.Repository) ([]*model.Repository, []model.RepositoryError) {
+	selection := grb.Selection()
+	if !slices.ContainsFunc(selection, func(field string) bool { return field == "languages" || slices.Contains(enrichments, field) }) {
+		return repositories, nil
+	}
+
+	return enrichCopies(ctx, repositories, func(ctx context.Context, repository *model.Repository) error {
+		languagesUrl := fmt.Sprintf("%s/repos/%s/%s/languages", GITHUB_REST_API_URL, url.PathEscape(repository.Owner), url.PathEscape(repository.Repository))
+		return gr.enrichFunc(ctx, repository, languagesUrl, selection)
+	})
+}
+
+func (gr *githubVersionnedApiRepository[T, M]) GetRateLimit(ctx context.Context, grb builder.GithubRequestBuilder) (model.RateLimit, error) {
+	return getRateLimit(ctx, grb, gr.httpProvider, gr.tokenProvider)
+}
+
+// Fetch the rate limit state from the Github REST API, shared by every GithubApiRepository implementations
+func getRateLimit(ctx context.Context, grb builder.GithubRequestBuilder, httpProvider providers.HttpProvider, tokenProvider providers.TokenProvider) (model.RateLimit, error) {
+	grb.Authorization(tokenProvider.Token(providers.RATE_LIMIT_RESOURCE_CORE))
+	req, err := grb.Build(ctx, http.MethodGet, "/rate_limit")
+
+	if err != nil {
+		return model.RateLimit{}, err
+	}
+
+	var apiResponse external.RateLimitResponse
+	if err = httpProvider.ReqUnmarshalledBody(req, &apiResponse); err != nil {
+		return model.RateLimit{}, err
+	}
+
+	toResource := func(name string) model.RateLimitResource {
+		resource := apiResponse.Resources[name]
+		return model.RateLimitResource{
+			Limit:     resource.Limit,
+			Remaining: resource.Remaining,
+			Used:      resource.Used,
+			Reset:     time.Unix(resource.Reset, 0).UTC(),
+		}
+	}
+
+	return model.RateLimit{
+		Core:    toResource("core"),
+		Search:  toResource("search"),
+		GraphQL: toResource("graphql"),
 	}, nil
 }
 
 // Factory method that creates a GithubApiRepository for a specific API version, err != nil if API version is not supported
-func NewGithubApiRepository(apiVersion version.GithubAPIVersion, httpProvider providers.HttpProvider, cacheProvider providers.CacheProvider, cacheDurationInMin time.Duration, githubToken string) (GithubApiRepository, error) {
+// Enrichments of the releases expand value are cached for releasesCacheDurationInMin
+func NewGithubApiRepository(apiVersion version.GithubAPIVersion, httpProvider providers.HttpProvider, cacheProvider providers.CacheProvider, cacheDurationInMin, releasesCacheDurationInMin time.Duration, tokenProvider providers.TokenProvider) (GithubApiRepository, error) {
 	switch apiVersion {
 	case version.GITHUB_API_2022_11_28:
-		return &githubVersionnedApiRepository[external.RepositoriesResponseItem, external.RepositoriesResponse]{
-			githubToken:        githubToken,
-			httpProvider:       httpProvider,
-			cacheProvider:      cacheProvider,
-			cacheDurationInMin: cacheDurationInMin,
-			// Mapper function converts items of the external model to our model
-			mapperFunc: func(ctx context.Context, rawRepository external.RepositoriesResponseItem) (*model.Repository, error) {
-				req, err := http.NewRequest(http.MethodGet, rawRepository.LanguagesUrl, nil)
+		// Shared by every mapping so that concurrent mappings of the same repository fetch its languages once
+		languagesCoalescer := util.NewCoalescer[external.Languages]()
+		enricher := newRepositoryEnricher(httpProvider, cacheProvider, cacheDurationInMin, releasesCacheDurationInMin, tokenProvider)
 
-				if err != nil {
-					return nil, nil
-				}
+		// Fetches the languages of a repository at languagesUrl, through the cache
+		fetchLanguages := func(ctx context.Context, languagesUrl string) (external.Languages, error) {
+			req, err := http.NewRequest(http.MethodGet, languagesUrl, nil)
 
-				requestUrl := rawRepository.LanguagesUrl
-				var repository model.Repository
+			if err != nil {
+				return nil, err
+			}
 
-				if err = cacheProvider.GetUnmarshalled(ctx, requestUrl, &repository); err == nil {
-					return &repository, nil
-				}
+			if githubToken := tokenProvider.Token(providers.RATE_LIMIT_RESOURCE_CORE); githubToken != "" {
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", githubToken))
+			}
 
-				if githubToken != "" {
-					req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", githubToken))
-				}
+			timeoutCtx, cancelTimeout := context.WithTimeout(ctx, time.Second*30)
+			defer cancelTimeout()
+			req = req.WithContext(timeoutCtx)
 
-				timeoutCtx, cancelTimeout := context.WithTimeout(ctx, time.Second*30)
-				defer cancelTimeout()
-				req = req.WithContext(timeoutCtx)
+			rawLanguages, err, _ := languagesCoalescer.Do(ctx, languagesUrl, func() (external.Languages, error) {
+				return providers.ReqCachedConditional[external.Languages](ctx, httpProvider, cacheProvider, req, time.Minute*cacheDurationInMin, revalidationDuration)
+			})
 
-				var rawLanguages external.Languages
-				err = httpProvider.ReqUnmarshalledBody(req, &rawLanguages)
+			return rawLanguages, err
+		}
 
-				languages := make(model.Language)
-				for k, v := range rawLanguages {
-					languages[k] = model.LanguageStats{
-						Bytes: v,
-					}
-				}
+		// Languages require one request per repository, they are only fetched when requested
+		enrich := func(ctx context.Context, repository *model.Repository, languagesUrl string, selection []string) error {
+			var languagesErr error
 
-				repository = model.Repository{
-					FullName:      rawRepository.FullName,
-					Owner:         rawRepository.Owner.Login,
-					Repository:    rawRepository.Name,
-					Description:   rawRepository.Description,
-					RepositoryUrl: fmt.Sprintf("https://github.com/%s", rawRepository.FullName),
-					Languages:     languages,
-					License: util.NullableJsonField[string]{
-						Value:  rawRepository.License.Value.Key,
-						IsNull: rawRepository.License.IsNull,
-					},
-					Size:      rawRepository.Size,
-					CreatedAt: rawRepository.CreatedAt,
-					UpdatedAt: rawRepository.UpdatedAt,
-				}
+			if slices.Contains(selection, "languages") {
+				rawLanguages, err := fetchLanguages(ctx, languagesUrl)
 
-				if err == nil {
-					_ = cacheProvider.SetMarshalled(ctx, requestUrl, repository, time.Minute*cacheDurationInMin)
+				if err != nil {
+					languagesErr = &EnrichmentError{Enrichment: "languages", Err: err}
+				} else {
+					repository.Languages = model.NewLanguages(rawLanguages)
 				}
+			}
+
+			return errors.Join(languagesErr, enricher.enrich(ctx, repository, selection))
+		}
 
-				return &repository, nil
+		return &githubVersionnedApiRepository[external.RepositoriesResponseItem, external.RepositoriesResponse]{
+			tokenProvider:      tokenProvider,
+			httpProvider:       httpProvider,
+			cacheProvider:      cacheProvider,
+			cacheDurationInMin: cacheDurationInMin,
+			enrichFunc:         enrich,
+			// Mapper function converts items of the external model to our model
+			mapperFunc: func(grb builder.GithubRequestBuilder) util.MapperFunc[external.RepositoriesResponseItem, *model.Repository] {
+				selection := grb.Selection()
+
+				// Repositories whose languages or enrichments failed are returned with these fields null, along with their *EnrichmentError
+				return func(ctx context.Context, rawRepository external.RepositoriesResponseItem) (*model.Repository, error) {
+					repository := model.Repository{
+						FullName:      rawRepository.FullName,
+						Owner:         rawRepository.Owner.Login,
+						Repository:    rawRepository.Name,
+						Description:   rawRepository.Description,
+						RepositoryUrl: fmt.Sprintf("https://github.com/%s", rawRepository.FullName),
+						Homepage: util.NullableJsonField[string]{
+							Value:  rawRepository.Homepage.Value,
+							IsNull: rawRepository.Homepage.IsNull || rawRepository.Homepage.Value == "",
+						},
+						PrimaryLanguage: rawRepository.Language,
+						License: util.NullableJsonField[model.License]{
+							Value: model.License{
+								Key:    rawRepository.License.Value.Key,
+								SpdxId: rawRepository.License.Value.SpdxId,
+								Name:   rawRepository.License.Value.Name,
+							},
+							IsNull: rawRepository.License.IsNull,
+						},
+						Topics:        append(make([]string, 0, len(rawRepository.Topics)), rawRepository.Topics...),
+						DefaultBranch: rawRepository.DefaultBranch,
+						Visibility:    rawRepository.Visibility,
+						Archived:      rawRepository.Archived,
+						Fork:          rawRepository.Fork,
+						Stars:         rawRepository.StargazersCount,
+						Forks:         rawRepository.ForksCount,
+						OpenIssues:    rawRepository.OpenIssuesCount,
+						Watchers:      rawRepository.WatchersCount,
+						Size:          rawRepository.Size,
+						CreatedAt:     rawRepository.CreatedAt,
+						UpdatedAt:     rawRepository.UpdatedAt,
+						PushedAt:      rawRepository.PushedAt,
+					}
+
+					// Languages and enrichments are fetched within the fan-out of the mapping
+					return &repository, enrich(ctx, &repository, rawRepository.LanguagesUrl, selection)
+				}
 			},
 		}, nil
 	default:
@@ -0,0 +1,311 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/builder"
+	"github.com/LasramR/sclng-backend-test-lasramR/model"
+	"github.com/LasramR/sclng-backend-test-lasramR/model/external"
+	"github.com/LasramR/sclng-backend-test-lasramR/providers"
+	"github.com/LasramR/sclng-backend-test-lasramR/util"
+)
+
+// Endpoint of the GitHub GraphQL API
+const GITHUB_GRAPHQL_API_URL = "https://api.github.com/graphql"
+
+// Fields fetched for each repository, languages are fetched in the same query to avoid one request per repository
+const graphQLRepositoryFields = `
+	name
+	nameWithOwner
+	description
+	url
+	owner { login }
+	homepageUrl
+	licenseInfo { key spdxId name }
+	repositoryTopics(first: 20) { nodes { topic { name } } }
+	defaultBranchRef { name }
+	visibility
+	isArchived
+	isFork
+	stargazerCount
+	forkCount
+	issues(states: OPEN) { totalCount }
+	watchers { totalCount }
+	createdAt
+	updatedAt
+	pushedAt
+	diskUsage
+	primaryLanguage { name }
+	languages(first: 100, orderBy: {field: SIZE, direction: DESC}) {
+		edges {
+			size
+			node { name }
+		}
+	}`
+
+const graphQLSearchQuery = `query($query: String!, $first: Int!, $after: String) {
+	search(query: $query, type: REPOSITORY, first: $first, after: $after) {
+		repositoryCount
+		nodes {
+			... on Repository {` + graphQLRepositoryFields + `
+			}
+		}
+	}
+}`
+
+const graphQLRepositoryQuery = `query($owner: String!, $name: String!) {
+	repository(owner: $owner, name: $name) {` + graphQLRepositoryFields + `
+	}
+}`
+
+// Implementation of the GitHub repository relying on the GraphQL API, it fetches a search result and its languages in a single request
+type githubGraphQLApiRepository struct {
+	graphqlUrl         string
+	tokenProvider      providers.TokenProvider
+	httpProvider       providers.HttpProvider
+	cacheProvider      providers.CacheProvider
+	cacheDurationInMin time.Duration
+	// Enrichments are not part of the GraphQL query, they are fetched from the REST API
+	enricher *repositoryEnricher
+}
+
+func (gr *githubGraphQLApiRepository) GetManyRepositories(ctx context.Context, grb builder.GithubRequestBuilder) (GithubRepositoriesResult, error) {
+	// The REST request built by the GithubRequestBuilder is used as a source of truth for the search parameters
+	restReq, err := grb.Build(ctx, http.MethodGet, "/search/repositories")
+
+	if err != nil {
+		return GithubRepositoriesResult{}, err
+	}
+
+	restParams := restReq.URL.Query()
+	searchQuery := restParams.Get("q")
+	if sort := restParams.Get("sort"); sort != "" {
+		searchQuery = strings.TrimSpace(fmt.Sprintf("%s sort:%s-%s", searchQuery, sort, restParams.Get("order")))
+	}
+
+	first, _ := strconv.Atoi(restParams.Get("per_page"))
+	page, _ := strconv.Atoi(restParams.Get("page"))
+
+	variables := map[string]any{
+		"query": searchQuery,
+		"first": first,
+		"after": nil,
+	}
+	// Github search cursors are the base64 encoded offset of the result. This format is undocumented, a page past the first one is
+	// thus forged from it and an empty page returned for it while more results matched is reported as ErrPageNotServed
+	if page > 1 {
+		variables["after"] = base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("cursor:%d", (page-1)*first)))
+	}
+
+	req, cacheKey, err := gr.buildRequest(ctx, graphQLSearchQuery, variables)
+
+	if err != nil {
+		return GithubRepositoriesResult{}, err
+	}
+
+	var repositories GithubRepositoriesResult
+
+	if err = gr.cacheProvider.GetUnmarshalled(ctx, cacheKey, &repositories); err == nil {
+		return gr.enrich(ctx, grb, repositories), nil
+	}
+
+	var apiResponse external.GraphQLSearchResponse
+	if err = gr.httpProvider.ReqUnmarshalledBody(req, &apiResponse); err != nil {
+		return GithubRepositoriesResult{}, err
+	}
+
+	if len(apiResponse.Errors) != 0 {
+		return GithubRepositoriesResult{}, graphQLErrorsToError(apiResponse.Errors)
+	}
+
+	if page > 1 && len(apiResponse.Items()) == 0 && (page-1)*first < apiResponse.Count() {
+		return GithubRepositoriesResult{}, ErrPageNotServed
+	}
+
+	mapped := make([]*model.Repository, 0, len(apiResponse.Items()))
+	for _, node := range apiResponse.Items() {
+		mapped = append(mapped, graphQLNodeToRepository(node))
+	}
+
+	repositories = GithubRepositoriesResult{
+		Repositories: mapped,
+		Total:        apiResponse.Count(),
+	}
+
+	_ = gr.cacheProvider.SetMarshalled(ctx, cacheKey, repositories, time.Minute*gr.cacheDurationInMin)
+
+	return gr.enrich(ctx, grb, repositories), nil
+}
+
+func (gr *githubGraphQLApiRepository) GetRepository(ctx context.Context, grb builder.GithubRequestBuilder, owner, name string) (*model.Repository, error) {
+	req, cacheKey, err := gr.buildRequest(ctx, graphQLRepositoryQuery, map[string]any{
+		"owner": owner,
+		"name":  name,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var repository model.Repository
+
+	if err = gr.cacheProvider.GetUnmarshalled(ctx, cacheKey, &repository); err == nil {
+		return &repository, nil
+	}
+
+	var apiResponse external.GraphQLRepositoryResponse
+	if err = gr.httpProvider.ReqUnmarshalledBody(req, &apiResponse); err != nil {
+		return nil, err
+	}
+
+	for _, graphQLErr := range apiResponse.Errors {
+		if graphQLErr.Type == "NOT_FOUND" {
+			return nil, ErrRepositoryNotFound
+		}
+	}
+
+	if len(apiResponse.Errors) != 0 {
+		return nil, graphQLErrorsToError(apiResponse.Errors)
+	}
+
+	if apiResponse.Data.Repository.IsNull {
+		return nil, ErrRepositoryNotFound
+	}
+
+	mapped := graphQLNodeToRepository(apiResponse.Data.Repository.Value)
+
+	_ = gr.cacheProvider.SetMarshalled(ctx, cacheKey, mapped, time.Minute*gr.cacheDurationInMin)
+
+	return mapped, nil
+}
+
+// Languages are fetched along with the repositories whatever the selection, only enrichments are left to fetch
+func (gr *githubGraphQLApiRepository) EnrichRepositories(ctx context.Context, grb builder.GithubRequestBuilder, repositories []*model.Repository) ([]*model.Repository, []model.RepositoryError) {
+	return gr.enricher.enrichAll(ctx, grb, repositories)
+}
+
+func (gr *githubGraphQLApiRepository) GetRateLimit(ctx context.Context, grb builder.GithubRequestBuilder) (model.RateLimit, error) {
+	return getRateLimit(ctx, grb, gr.httpProvider, gr.tokenProvider)
+}
+
+// Fetches the enrichments requested by grb into repositories, which are cached without them as enrichments are cached per repository
+func (gr *githubGraphQLApiRepository) enrich(ctx context.Context, grb builder.GithubRequestBuilder, repositories GithubRepositoriesResult) GithubRepositoriesResult {
+	enriched, repositoryErrs := gr.enricher.enrichAll(ctx, grb, repositories.Repositories)
+
+	repositories.Repositories = enriched
+	repositories.Errors = append(repositories.Errors, repositoryErrs...)
+	repositories.IncompleteResult = len(repositories.Errors) != 0
+
+	return repositories
+}
+
+// Build the POST request of a GraphQL query, also returns the key used to cache its result
+func (gr *githubGraphQLApiRepository) buildRequest(ctx context.Context, query string, variables map[string]any) (*http.Request, string, error) {
+	body, err := json.Marshal(external.GraphQLRequest{
+		Query:     query,
+		Variables: variables,
+	})
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, gr.graphqlUrl, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if githubToken := gr.tokenProvider.Token(providers.RATE_LIMIT_RESOURCE_GRAPHQL); githubToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", githubToken))
+	}
+
+	// Variables are marshalled with sorted keys which keeps the cache key deterministic
+	return req, fmt.Sprintf("%s#%s", gr.graphqlUrl, body), nil
+}
+
+// Converts a repository node of the GraphQL API to our model
+func graphQLNodeToRepository(node external.GraphQLRepositoryNode) *model.Repository {
+	bytesPerLanguage := make(map[string]int, len(node.Languages.Edges))
+	for _, edge := range node.Languages.Edges {
+		bytesPerLanguage[edge.Node.Name] = edge.Size
+	}
+
+	topics := make([]string, 0, len(node.RepositoryTopics.Nodes))
+	for _, topicNode := range node.RepositoryTopics.Nodes {
+		topics = append(topics, topicNode.Topic.Name)
+	}
+
+	return &model.Repository{
+		FullName:      node.NameWithOwner,
+		Owner:         node.Owner.Login,
+		Repository:    node.Name,
+		Description:   node.Description,
+		RepositoryUrl: node.Url,
+		Homepage: util.NullableJsonField[string]{
+			Value:  node.HomepageUrl.Value,
+			IsNull: node.HomepageUrl.IsNull || node.HomepageUrl.Value == "",
+		},
+		Languages: model.NewLanguages(bytesPerLanguage),
+		PrimaryLanguage: util.NullableJsonField[string]{
+			Value:  node.PrimaryLanguage.Value.Name,
+			IsNull: node.PrimaryLanguage.IsNull,
+		},
+		License: util.NullableJsonField[model.License]{
+			Value: model.License{
+				Key:    node.LicenseInfo.Value.Key,
+				SpdxId: node.LicenseInfo.Value.SpdxId,
+				Name:   node.LicenseInfo.Value.Name,
+			},
+			IsNull: node.LicenseInfo.IsNull,
+		},
+		Topics: topics,
+		// Repositories without commits have no default branch
+		DefaultBranch: node.DefaultBranchRef.Value.Name,
+		// GraphQL enums are upper cased, eg PUBLIC
+		Visibility: strings.ToLower(node.Visibility),
+		Archived:   node.IsArchived,
+		Fork:       node.IsFork,
+		Stars:      node.StargazerCount,
+		Forks:      node.ForkCount,
+		OpenIssues: node.Issues.TotalCount,
+		Watchers:   node.Watchers.TotalCount,
+		Size:       node.DiskUsage,
+		CreatedAt:  node.CreatedAt,
+		UpdatedAt:  node.UpdatedAt,
+		PushedAt:   node.PushedAt,
+	}
+}
+
+// Merges the errors of a GraphQL response into a single error
+func graphQLErrorsToError(graphQLErrors []external.GraphQLError) error {
+	messages := make([]string, 0, len(graphQLErrors))
+	for _, graphQLErr := range graphQLErrors {
+		messages = append(messages, graphQLErr.Message)
+	}
+
+	return errors.New(strings.Join(messages, ", "))
+}
+
+// Factory method that creates a GithubApiRepository relying on the GitHub GraphQL API available at graphqlUrl.
+// Enrichments of the releases expand value are cached for releasesCacheDurationInMin
+func NewGithubGraphQLApiRepository(graphqlUrl string, httpProvider providers.HttpProvider, cacheProvider providers.CacheProvider, cacheDurationInMin, releasesCacheDurationInMin time.Duration, tokenProvider providers.TokenProvider) GithubApiRepository {
+	return &githubGraphQLApiRepository{
+		graphqlUrl:         graphqlUrl,
+		tokenProvider:      tokenProvider,
+		httpProvider:       httpProvider,
+		cacheProvider:      cacheProvider,
+		cacheDurationInMin: cacheDurationInMin,
+		enricher:           newRepositoryEnricher(httpProvider, cacheProvider, cacheDurationInMin, releasesCacheDurationInMin, tokenProvider),
+	}
+}
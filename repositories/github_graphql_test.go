@@ -0,0 +1,216 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/builder"
+	"github.com/LasramR/sclng-backend-test-lasramR/model"
+	"github.com/LasramR/sclng-backend-test-lasramR/model/external"
+	"github.com/LasramR/sclng-backend-test-lasramR/model/version"
+	"github.com/LasramR/sclng-backend-test-lasramR/providers"
+	"github.com/LasramR/sclng-backend-test-lasramR/util"
+)
+
+// Fake GraphQL server responding with body and recording the received requests
+func MockGraphQLServer(t *testing.T, body string, received *[]external.GraphQLRequest) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var graphQLRequest external.GraphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&graphQLRequest); err != nil {
+			t.Errorf("GraphQL server should receive a valid GraphQL request")
+		}
+
+		if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer sometoken" {
+			t.Errorf("GraphQL request should be an authenticated POST request")
+		}
+
+		*received = append(*received, graphQLRequest)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestGraphQLGetManyRepositories(t *testing.T) {
+	received := make([]external.GraphQLRequest, 0)
+	server := MockGraphQLServer(t, GITHUB_GRAPHQL_SEARCH_RESPONSE_BODY_SAMPLE, &received)
+
+	gr := NewGithubGraphQLApiRepository(
+		server.URL,
+		providers.NewNativeHttpProvider(providers.NativeHttpClient{Do: server.Client().Do}),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	_ = grb.With("language", "Go")
+	_ = grb.Sort("stars")
+	_ = grb.Limit(2)
+	_ = grb.Page(3)
+
+	result, err := gr.GetManyRepositories(context.Background(), grb)
+
+	if err != nil {
+		t.Fatalf("Should not have returned an error")
+	}
+
+	if len(received) != 1 {
+		t.Fatalf("Search and languages should have been fetched in a single request, got %d", len(received))
+	}
+
+	variables := received[0].Variables
+	if variables["query"] != "is:public language:Go sort:stars-desc" || variables["first"] != float64(2) || variables["after"] != "Y3Vyc29yOjQ=" {
+		t.Fatalf("GraphQL variables should be built from the GithubRequestBuilder, got %v", variables)
+	}
+
+	expected := &model.Repository{
+		FullName:      "fmuiin14/BlazingTool",
+		Owner:         "fmuiin14",
+		Description:   "Brute force ethereum wallet mnemonics",
+		Repository:    "BlazingTool",
+		RepositoryUrl: "https://github.com/fmuiin14/BlazingTool",
+		Homepage:      util.NullableJsonField[string]{IsNull: true},
+		License: util.NullableJsonField[model.License]{
+			Value:  model.License{Key: "mit", SpdxId: "MIT", Name: "MIT License"},
+			IsNull: false,
+		},
+		Topics:        []string{"ethereum"},
+		DefaultBranch: "main",
+		Visibility:    "public",
+		Stars:         42,
+		Forks:         7,
+		OpenIssues:    3,
+		Watchers:      5,
+		CreatedAt:     time.Date(2024, time.October, 19, 10, 17, 16, 0, time.UTC),
+		UpdatedAt:     time.Date(2024, time.October, 20, 16, 36, 13, 0, time.UTC),
+		PushedAt:      util.NullableJsonField[time.Time]{Value: time.Date(2024, time.October, 20, 16, 30, 0, 0, time.UTC)},
+		Languages:     model.NewLanguages(map[string]int{"JavaScript": 1548, "SCSS": 250}),
+		Size:          156464,
+	}
+
+	if result.Total != 606814 || len(result.Repositories) != 2 {
+		t.Fatalf("Should have returned two records out of 606814")
+	}
+
+	if !reflect.DeepEqual(result.Repositories[0], expected) {
+		t.Fatalf("Should equals expected value %v, got %v", expected, result.Repositories[0])
+	}
+
+	if !result.Repositories[1].License.IsNull {
+		t.Fatalf("Missing license should be null")
+	}
+}
+
+func TestGraphQLGetManyRepositories_PageNotServed(t *testing.T) {
+	received := make([]external.GraphQLRequest, 0)
+	// Github ignored the forged cursor of the page
+	server := MockGraphQLServer(t, `{"data": {"search": {"repositoryCount": 606814, "nodes": []}}}`, &received)
+
+	gr := NewGithubGraphQLApiRepository(
+		server.URL,
+		providers.NewNativeHttpProvider(providers.NativeHttpClient{Do: server.Client().Do}),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	_ = grb.Limit(2)
+	_ = grb.Page(3)
+
+	_, err := gr.GetManyRepositories(context.Background(), grb)
+
+	if !errors.Is(err, ErrPageNotServed) {
+		t.Fatalf("Should have reported the page was not served, got %v", err)
+	}
+}
+
+func TestGraphQLGetRepository_NotFound(t *testing.T) {
+	received := make([]external.GraphQLRequest, 0)
+	server := MockGraphQLServer(t, `{"data":{"repository":null},"errors":[{"type":"NOT_FOUND","message":"Could not resolve to a Repository"}]}`, &received)
+
+	gr := NewGithubGraphQLApiRepository(
+		server.URL,
+		providers.NewNativeHttpProvider(providers.NativeHttpClient{Do: server.Client().Do}),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	_, err := gr.GetRepository(context.Background(), nil, "fmuiin14", "Unknown")
+
+	if !errors.Is(err, ErrRepositoryNotFound) {
+		t.Fatalf("Should have returned ErrRepositoryNotFound")
+	}
+
+	if received[0].Variables["owner"] != "fmuiin14" || received[0].Variables["name"] != "Unknown" {
+		t.Fatalf("GraphQL variables should contain the repository owner and name")
+	}
+}
+
+const GITHUB_GRAPHQL_SEARCH_RESPONSE_BODY_SAMPLE = `
+{
+  "data": {
+    "search": {
+      "repositoryCount": 606814,
+      "nodes": [
+        {
+          "name": "BlazingTool",
+          "nameWithOwner": "fmuiin14/BlazingTool",
+          "description": "Brute force ethereum wallet mnemonics",
+          "url": "https://github.com/fmuiin14/BlazingTool",
+          "owner": { "login": "fmuiin14" },
+          "homepageUrl": "",
+          "licenseInfo": { "key": "mit", "spdxId": "MIT", "name": "MIT License" },
+          "repositoryTopics": { "nodes": [{ "topic": { "name": "ethereum" } }] },
+          "defaultBranchRef": { "name": "main" },
+          "visibility": "PUBLIC",
+          "isArchived": false,
+          "isFork": false,
+          "stargazerCount": 42,
+          "forkCount": 7,
+          "issues": { "totalCount": 3 },
+          "watchers": { "totalCount": 5 },
+          "createdAt": "2024-10-19T10:17:16Z",
+          "updatedAt": "2024-10-20T16:36:13Z",
+          "pushedAt": "2024-10-20T16:30:00Z",
+          "diskUsage": 156464,
+          "languages": {
+            "edges": [
+              { "size": 1548, "node": { "name": "JavaScript" } },
+              { "size": 250, "node": { "name": "SCSS" } }
+            ]
+          }
+        },
+        {
+          "name": "ShadowTool",
+          "nameWithOwner": "fmuiin14/ShadowTool",
+          "description": null,
+          "url": "https://github.com/fmuiin14/ShadowTool",
+          "owner": { "login": "fmuiin14" },
+          "licenseInfo": null,
+          "createdAt": "2024-10-19T10:20:11Z",
+          "updatedAt": "2024-10-20T16:36:05Z",
+          "diskUsage": 9856,
+          "languages": {
+            "edges": [
+              { "size": 10884, "node": { "name": "TypeScript" } }
+            ]
+          }
+        }
+      ]
+    }
+  }
+}`
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -38,7 +39,8 @@ func MockHttpProvider(bodys []string, errs []error) providers.HttpProvider {
 				}
 
 				resp := &http.Response{
-					Body: io.NopCloser(bytes.NewReader([]byte(bodys[bodyIdx]))),
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader([]byte(bodys[bodyIdx]))),
 				}
 
 				i += 1
@@ -81,7 +83,8 @@ func TestGetGithubProjectsWithStats_API20221128(t *testing.T) {
 		),
 		MochCacheProvider("", errors.New("no value in cache"), nil),
 		5,
-		"sometoken",
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
 	)
 
 	if err != nil {
@@ -99,22 +102,15 @@ func TestGetGithubProjectsWithStats_API20221128(t *testing.T) {
 				Owner:       "fmuiin14",
 				Description: "Brute force ethereum wallet mnemonics",
 				Repository:  "fmuiin14/BlazingTool",
-				License: util.NullableJsonField[string]{
-					Value:  "mit",
+				License: util.NullableJsonField[model.License]{
+					Value:  model.License{Key: "mit", SpdxId: "MIT", Name: "MIT License"},
 					IsNull: false,
 				},
 				RepositoryUrl: "https://github.com/fmuiin14/BlazingTool",
-				CreatedAt:     "2024-10-19T10:17:16Z",
-				UpdatedAt:     "2024-10-20T16:36:13Z",
-				Languages: model.Language{
-					"JavaScript": model.LanguageStats{
-						Bytes: 1548,
-					},
-					"SCSS": model.LanguageStats{
-						Bytes: 250,
-					},
-				},
-				Size: 156464,
+				CreatedAt:     time.Date(2024, time.October, 19, 10, 17, 16, 0, time.UTC),
+				UpdatedAt:     time.Date(2024, time.October, 20, 16, 36, 13, 0, time.UTC),
+				Languages:     model.NewLanguages(map[string]int{"JavaScript": 1548, "SCSS": 250}),
+				Size:          156464,
 			},
 		},
 	}
@@ -128,7 +124,267 @@ func TestGetGithubProjectsWithStats_API20221128(t *testing.T) {
 	}
 }
 
+func TestGetGithubProjectsWithStats_WithoutLanguages(t *testing.T) {
+	requests := 0
+	gr, _ := NewGithubApiRepository(
+		version.GITHUB_API_2022_11_28,
+		providers.NewNativeHttpProvider(providers.NativeHttpClient{
+			Do: func(req *http.Request) (*http.Response, error) {
+				requests++
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader([]byte(GITHUB_SEARCH_REPOS_RESPONSE_BODY_SAMPLE))),
+				}, nil
+			},
+		}),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	_ = grb.Fields("full_name,license")
+
+	result, err := gr.GetManyRepositories(context.Background(), grb)
+
+	if err != nil || len(result.Repositories) != 2 || result.Repositories[0].FullName == "" {
+		t.Fatalf("Should have mapped the repositories")
+	}
+
+	if requests != 1 {
+		t.Fatalf("Should not have fetched languages when they are not requested, made %d requests", requests)
+	}
+}
+
+func TestGetGithubProjectsWithStats_LanguagesFailure(t *testing.T) {
+	gr, _ := NewGithubApiRepository(
+		version.GITHUB_API_2022_11_28,
+		providers.NewNativeHttpProvider(providers.NativeHttpClient{
+			Do: func(req *http.Request) (*http.Response, error) {
+				if strings.HasSuffix(req.URL.Path, "/languages") {
+					return nil, errors.New("connection reset")
+				}
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader([]byte(GITHUB_SEARCH_REPOS_RESPONSE_BODY_SAMPLE))),
+				}, nil
+			},
+		}),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	result, err := gr.GetManyRepositories(context.Background(), grb)
+
+	if err != nil || len(result.Repositories) != 2 || result.Repositories[0] == nil || result.Repositories[0].Languages != nil {
+		t.Fatalf("Repositories whose languages failed should be returned with null languages")
+	}
+
+	if !result.IncompleteResult || len(result.Errors) != 2 || result.Errors[0].Enrichment != "languages" || result.Errors[1].FullName != "fmuiin14/ShadowTool" {
+		t.Fatalf("Should have reported the failed languages of each repository, got %v", result.Errors)
+	}
+}
+
+func TestGetGithubProjectsWithStats_BuildFailure(t *testing.T) {
+	gr, _ := NewGithubApiRepository(
+		version.GITHUB_API_2022_11_28,
+		MockHttpProvider([]string{GITHUB_SEARCH_REPOS_RESPONSE_BODY_SAMPLE}, []error{nil}),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	// Requests with alternative values must be split before being built
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	_ = grb.With("language", "go")
+	_ = grb.With("language", "rust")
+
+	_, err := gr.GetManyRepositories(context.Background(), grb)
+
+	if err == nil {
+		t.Fatalf("Should have returned the error of the request building")
+	}
+}
+
+func TestGetRepository_API20221128(t *testing.T) {
+	gr, _ := NewGithubApiRepository(
+		version.GITHUB_API_2022_11_28,
+		MockHttpProvider(
+			[]string{GITHUB_REPO_RESPONSE_BODY_SAMPLE, GITHUB_LANGUAGE_RESPONSE_BODY_SAMPLE_1},
+			nil,
+		),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	result, err := gr.GetRepository(context.Background(), grb, "fmuiin14", "BlazingTool")
+
+	if err != nil {
+		t.Fatalf("Should not have returned an error")
+	}
+
+	if result.FullName != "fmuiin14/BlazingTool" || result.Languages[0].Name != "JavaScript" || result.Languages[0].Bytes != 1548 {
+		t.Fatalf("Should have mapped the repository with its languages")
+	}
+
+	if result.Stars != 42 || result.Forks != 7 || result.OpenIssues != 3 || !result.Fork || result.DefaultBranch != "main" || !reflect.DeepEqual(result.Topics, []string{"ethereum", "wallet"}) {
+		t.Fatalf("Should have mapped the repository counters and metadata")
+	}
+
+	if result.License.Value.SpdxId != "MIT" || result.License.Value.Name != "MIT License" || result.Homepage.Value != "https://blazing.tool" || !result.PushedAt.IsNull {
+		t.Fatalf("Should have mapped the repository license, homepage and null pushed date")
+	}
+
+	if !result.CreatedAt.Equal(time.Date(2024, time.October, 19, 10, 17, 16, 0, time.UTC)) {
+		t.Fatalf("Should have parsed the creation date")
+	}
+}
+
+func TestGetRepository_LanguagesFailure(t *testing.T) {
+	gr, _ := NewGithubApiRepository(
+		version.GITHUB_API_2022_11_28,
+		providers.NewNativeHttpProvider(providers.NativeHttpClient{
+			Do: func(req *http.Request) (*http.Response, error) {
+				if strings.HasSuffix(req.URL.Path, "/languages") {
+					return nil, errors.New("connection reset")
+				}
+
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader([]byte(GITHUB_REPO_RESPONSE_BODY_SAMPLE))),
+				}, nil
+			},
+		}),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	result, err := gr.GetRepository(context.Background(), grb, "fmuiin14", "BlazingTool")
+
+	if result == nil || result.FullName != "fmuiin14/BlazingTool" || result.Languages != nil {
+		t.Fatalf("Repository whose languages failed should be returned with null languages")
+	}
+
+	var enrichmentErr *EnrichmentError
+	if !errors.As(err, &enrichmentErr) || enrichmentErr.Enrichment != "languages" {
+		t.Fatalf("Should have returned the error of the failed languages, got %v", err)
+	}
+
+	repositoryErrs := RepositoryErrorsOf(result, err)
+	if len(repositoryErrs) != 1 || repositoryErrs[0].FullName != "fmuiin14/BlazingTool" || repositoryErrs[0].Enrichment != "languages" {
+		t.Fatalf("Should have converted the error of the failed languages, got %v", repositoryErrs)
+	}
+}
+
+func TestGetRepository_NotFound(t *testing.T) {
+	gr, _ := NewGithubApiRepository(
+		version.GITHUB_API_2022_11_28,
+		providers.NewNativeHttpProvider(providers.NativeHttpClient{
+			Do: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusNotFound,
+					Body:       io.NopCloser(bytes.NewReader([]byte(`{"message":"Not Found"}`))),
+				}, nil
+			},
+		}),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	_, err := gr.GetRepository(context.Background(), grb, "fmuiin14", "Unknown")
+
+	if !errors.Is(err, ErrRepositoryNotFound) {
+		t.Fatalf("Should have returned ErrRepositoryNotFound")
+	}
+}
+
+func TestGetRateLimit_API20221128(t *testing.T) {
+	gr, _ := NewGithubApiRepository(
+		version.GITHUB_API_2022_11_28,
+		MockHttpProvider(
+			[]string{GITHUB_RATE_LIMIT_RESPONSE_BODY_SAMPLE},
+			nil,
+		),
+		MochCacheProvider("", errors.New("no value in cache"), nil),
+		5,
+		60,
+		providers.NewTokenPool([]string{"sometoken"}),
+	)
+
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	result, err := gr.GetRateLimit(context.Background(), grb)
+
+	if err != nil {
+		t.Fatalf("Should not have returned an error")
+	}
+
+	expected := model.RateLimitResource{Limit: 30, Remaining: 18, Used: 12, Reset: time.Unix(1729500000, 0).UTC()}
+	if result.Core.Remaining != 4999 || !reflect.DeepEqual(result.Search, expected) {
+		t.Fatalf("Should have mapped the rate limit resources")
+	}
+}
+
 const (
+	GITHUB_RATE_LIMIT_RESPONSE_BODY_SAMPLE = `
+{
+  "resources": {
+    "core": { "limit": 5000, "remaining": 4999, "used": 1, "reset": 1729500000 },
+    "search": { "limit": 30, "remaining": 18, "used": 12, "reset": 1729500000 },
+    "graphql": { "limit": 5000, "remaining": 5000, "used": 0, "reset": 1729500000 }
+  }
+}`
+
+	GITHUB_REPO_RESPONSE_BODY_SAMPLE = `
+{
+  "id": 875186168,
+  "name": "BlazingTool",
+  "full_name": "fmuiin14/BlazingTool",
+  "owner": {
+    "login": "fmuiin14"
+  },
+  "size": 156464,
+  "description": "Brute force ethereum wallet mnemonics",
+  "languages_url": "https://api.github.com/repos/fmuiin14/BlazingTool/languages",
+  "created_at": "2024-10-19T10:17:16Z",
+  "updated_at": "2024-10-20T16:36:13Z",
+  "pushed_at": null,
+  "stargazers_count": 42,
+  "forks_count": 7,
+  "open_issues_count": 3,
+  "watchers_count": 42,
+  "topics": ["ethereum", "wallet"],
+  "default_branch": "main",
+  "archived": false,
+  "fork": true,
+  "homepage": "https://blazing.tool",
+  "visibility": "public",
+  "license": {
+    "key": "mit",
+    "name": "MIT License",
+    "spdx_id": "MIT"
+  }
+}`
+
 	GITHUB_SEARCH_REPOS_RESPONSE_BODY_SAMPLE = `
 {
   "total_count": 606814,
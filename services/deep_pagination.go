@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/builder"
+	"github.com/LasramR/sclng-backend-test-lasramR/repositories"
+)
+
+// Returned when a deep pagination cursor can't be decoded
+var ErrInvalidCursor = errors.New("invalid cursor parameter")
+
+// No Github repository was created before this date, lower bound of the created windows
+var githubEpoch = time.Date(2007, time.October, 1, 0, 0, 0, 0, time.UTC)
+
+// Maximum number of requests spent looking for the next created window
+const maxWindowProbes = 8
+
+// Position of a deep pagination walk : a page of a created window holding at most GITHUB_SEARCH_RESULTS_CAP repositories.
+// Windows are walked from the most recent to the oldest, Index is the page number accross the whole walk
+type searchCursor struct {
+	From  int64 `json:"f"`
+	To    int64 `json:"t"`
+	Page  int   `json:"p"`
+	Total int   `json:"n"`
+	Limit int   `json:"l"`
+	Index int   `json:"i"`
+}
+
+// Encodes the cursor as an opaque url safe string
+func (c searchCursor) encode() string {
+	marshalled, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(marshalled)
+}
+
+// Decodes a cursor encoded with encode, error is ErrInvalidCursor if cursor is malformed
+func decodeSearchCursor(cursor string) (searchCursor, error) {
+	var decoded searchCursor
+
+	marshalled, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || json.Unmarshal(marshalled, &decoded) != nil || decoded.Page < 1 || decoded.Index < 1 || decoded.Limit < 1 || decoded.From > decoded.To {
+		return searchCursor{}, ErrInvalidCursor
+	}
+
+	return decoded, nil
+}
+
+// Walks the repositories matching grb from the page described by cursor, an empty cursor starts the walk.
+// Github only serves the first 1000 results of a query, the query is thus sliced into created windows holding less results
+func (gs *githubServiceImpl) getGithubProjectsAfter(ctx context.Context, grb builder.GithubRequestBuilder, cursor string) (repositories.GithubRepositoriesResult, error) {
+	var current searchCursor
+
+	if cursor == "" {
+		limit, _ := grb.Paging()
+		now := time.Now().UTC().Truncate(time.Second)
+		total, err := gs.countCreatedWindow(ctx, grb, githubEpoch, now)
+		if err != nil {
+			return repositories.GithubRepositoriesResult{}, err
+		}
+
+		current = searchCursor{From: githubEpoch.Unix(), To: now.Unix(), Page: 1, Total: total, Limit: limit, Index: 1}
+
+		if total > builder.GITHUB_SEARCH_RESULTS_CAP {
+			window, _, err := gs.findCreatedWindow(ctx, grb, now, now.Sub(githubEpoch)/2)
+			if err != nil {
+				return repositories.GithubRepositoriesResult{}, err
+			}
+			window.Total, window.Limit, window.Index = total, limit, 1
+			current = window
+		}
+	} else {
+		decoded, err := decodeSearchCursor(cursor)
+		if err != nil {
+			return repositories.GithubRepositoriesResult{}, err
+		}
+		current = decoded
+	}
+
+	// The cursor may not belong to this request
+	windowGrb, err := grb.CreatedWindow(time.Unix(current.From, 0), time.Unix(current.To, 0))
+	if err != nil {
+		return repositories.GithubRepositoriesResult{}, ErrInvalidCursor
+	}
+
+	// The cursor limit replaces the limit of the request
+	if windowGrb.Limit(current.Limit) != nil || windowGrb.Page(current.Page) != nil {
+		return repositories.GithubRepositoriesResult{}, ErrInvalidCursor
+	}
+
+	result, err := gs.GithubRepository.GetManyRepositories(ctx, windowGrb)
+	if err != nil {
+		return repositories.GithubRepositoriesResult{}, err
+	}
+
+	// Windows left too large by findCreatedWindow are walked up to the first GITHUB_SEARCH_RESULTS_CAP results only
+	windowTotal := min(result.Total, builder.GITHUB_SEARCH_RESULTS_CAP)
+	result.Total = current.Total
+	result.CursorPage, result.CursorLimit = current.Index, current.Limit
+
+	if current.Page*current.Limit < windowTotal {
+		next := current
+		next.Page++
+		next.Index++
+		result.NextCursor = next.encode()
+		return result, nil
+	}
+
+	// Current window is exhausted, looks for the next older one so that next is null at the real end
+	to := time.Unix(current.From, 0).Add(-time.Second)
+	if to.Before(githubEpoch) {
+		return result, nil
+	}
+
+	next, found, err := gs.findCreatedWindow(ctx, grb, to, time.Duration(current.To-current.From)*time.Second)
+	if err != nil {
+		return repositories.GithubRepositoriesResult{}, err
+	}
+
+	if found {
+		next.Total, next.Limit, next.Index = current.Total, current.Limit, current.Index+1
+		result.NextCursor = next.encode()
+	}
+
+	return result, nil
+}
+
+// Looks for a created window ending at to and holding between 1 and GITHUB_SEARCH_RESULTS_CAP repositories, starting with a window of span.
+// The span is halved while the window holds too many repositories and doubled while it holds none. found is false if no repository was created before to
+func (gs *githubServiceImpl) findCreatedWindow(ctx context.Context, grb builder.GithubRequestBuilder, to time.Time, span time.Duration) (searchCursor, bool, error) {
+	to = to.UTC().Truncate(time.Second)
+	span = max(span.Truncate(time.Second), time.Second)
+	// Spans known to hold no repository and too many repositories
+	var tooSmall, tooLarge time.Duration
+
+	for probe := 0; ; probe++ {
+		from := to.Add(-span)
+		if from.Before(githubEpoch) {
+			from = githubEpoch
+			span = to.Sub(from)
+		}
+
+		total, err := gs.countCreatedWindow(ctx, grb, from, to)
+		if err != nil {
+			return searchCursor{}, false, err
+		}
+
+		window := searchCursor{From: from.Unix(), To: to.Unix(), Page: 1, Total: total}
+
+		switch {
+		case total == 0 && from.Equal(githubEpoch):
+			return searchCursor{}, false, nil
+		case total == 0:
+			tooSmall = span
+		case total > builder.GITHUB_SEARCH_RESULTS_CAP && span > time.Second && probe < maxWindowProbes-1:
+			tooLarge = span
+		default:
+			// Past the probes budget, repositories beyond the first 1000 of the window are skipped rather than spending more requests
+			return window, true, nil
+		}
+
+		if probe >= maxWindowProbes-1 && tooLarge != 0 {
+			return searchCursor{From: to.Add(-tooLarge).Unix(), To: to.Unix(), Page: 1}, true, nil
+		}
+
+		if tooSmall != 0 && tooLarge != 0 {
+			span = (tooSmall + tooLarge) / 2
+		} else if tooLarge != 0 {
+			span = tooLarge / 2
+		} else {
+			span = tooSmall * 2
+		}
+		span = max(span.Truncate(time.Second), time.Second)
+	}
+}
+
+// Counts the repositories matching grb created between from and to with a single result request, fetched without languages nor enrichments
+func (gs *githubServiceImpl) countCreatedWindow(ctx context.Context, grb builder.GithubRequestBuilder, from, to time.Time) (int, error) {
+	windowGrb, err := grb.Minimal().CreatedWindow(from, to)
+	if err != nil {
+		// The window does not intersect the created parameter of the request
+		return 0, nil
+	}
+
+	_ = windowGrb.Limit(1)
+	_ = windowGrb.Page(1)
+
+	result, err := gs.GithubRepository.GetManyRepositories(ctx, windowGrb)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.Total, nil
+}
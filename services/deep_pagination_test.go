@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LasramR/sclng-backend-test-lasramR/builder"
+	"github.com/LasramR/sclng-backend-test-lasramR/model"
+	"github.com/LasramR/sclng-backend-test-lasramR/model/version"
+	"github.com/LasramR/sclng-backend-test-lasramR/repositories"
+)
+
+// Fake Github search over repositories created at the given dates, serving only the first 1000 results of a query
+type MockWindowedGithubRepository struct {
+	MockGithubRepository
+	createdAt []time.Time
+	requests  int
+	// Number of count requests selecting more than the full_name field
+	enrichedCounts int
+}
+
+func (mgr *MockWindowedGithubRepository) GetManyRepositories(ctx context.Context, grb builder.GithubRequestBuilder) (repositories.GithubRepositoriesResult, error) {
+	mgr.requests++
+	req, err := grb.Build(ctx, http.MethodGet, "/search/repositories")
+
+	if err != nil {
+		return repositories.GithubRepositoriesResult{}, err
+	}
+
+	from, to := time.Time{}, time.Now()
+	for _, term := range strings.Split(req.URL.Query().Get("q"), " ") {
+		if window, ok := strings.CutPrefix(term, "created:"); ok {
+			bounds := strings.Split(window, "..")
+			from, _ = time.Parse(time.RFC3339, bounds[0])
+			to, _ = time.Parse(time.RFC3339, bounds[1])
+		}
+	}
+
+	matching := make([]*model.Repository, 0)
+	for i, createdAt := range mgr.createdAt {
+		if !createdAt.Before(from) && !createdAt.After(to) {
+			matching = append(matching, &model.Repository{FullName: fmt.Sprintf("owner/repo%d", i)})
+		}
+	}
+
+	limit, _ := strconv.Atoi(req.URL.Query().Get("per_page"))
+	if limit == 1 && !reflect.DeepEqual(grb.Selection(), []string{"full_name"}) {
+		mgr.enrichedCounts++
+	}
+
+	page, _ := strconv.Atoi(req.URL.Query().Get("page"))
+	start, end := min((page-1)*limit, len(matching)), min(page*limit, len(matching))
+
+	if end > builder.GITHUB_SEARCH_RESULTS_CAP {
+		return repositories.GithubRepositoriesResult{}, errors.New("only the first 1000 search results are available")
+	}
+
+	return repositories.GithubRepositoriesResult{
+		Total:        len(matching),
+		Repositories: matching[start:end],
+	}, nil
+}
+
+func TestGetGithubProjectsAfter_WalksPastSearchCap(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	createdAt := make([]time.Time, 2500)
+	for i := range createdAt {
+		createdAt[i] = now.Add(-time.Duration(i) * 12 * time.Hour)
+	}
+
+	mgr := &MockWindowedGithubRepository{createdAt: createdAt}
+	gs := NewGithubService(mgr)
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	_ = grb.Expand("contributors")
+
+	seen := make(map[string]bool)
+	cursor := ""
+	for pages := 0; pages < 100; pages++ {
+		result, err := gs.GetGithubProjectsAfter(context.Background(), grb, cursor)
+
+		if err != nil {
+			t.Fatalf("Should not have returned an error, got %s", err)
+		}
+
+		if result.Total != len(createdAt) {
+			t.Fatalf("Should have reported the total of the whole walk")
+		}
+
+		if result.CursorPage != pages+1 {
+			t.Fatalf("Should have reported page %d of the walk, got %d", pages+1, result.CursorPage)
+		}
+
+		for _, repository := range result.Repositories {
+			if seen[repository.FullName] {
+				t.Fatalf("%s should have been walked once", repository.FullName)
+			}
+			seen[repository.FullName] = true
+		}
+
+		if cursor = result.NextCursor; cursor == "" {
+			break
+		}
+	}
+
+	if len(seen) != len(createdAt) {
+		t.Fatalf("Should have walked every repository, walked %d", len(seen))
+	}
+
+	if mgr.enrichedCounts != 0 {
+		t.Fatalf("Windows should have been counted without languages nor enrichments, %d counts were not", mgr.enrichedCounts)
+	}
+}
+
+func TestGetGithubProjectsAfter_DenseWindow(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	// More repositories than Github serves are created in the same second, the window can't be sliced further
+	createdAt := make([]time.Time, 1500, 1550)
+	for i := range createdAt {
+		createdAt[i] = now.Add(-time.Hour)
+	}
+	for i := 0; i < 50; i++ {
+		createdAt = append(createdAt, now.Add(-time.Duration(i+2)*time.Hour))
+	}
+
+	mgr := &MockWindowedGithubRepository{createdAt: createdAt}
+	gs := NewGithubService(mgr)
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	seen := make(map[string]bool)
+	cursor := ""
+	for pages := 0; pages < 100; pages++ {
+		result, err := gs.GetGithubProjectsAfter(context.Background(), grb, cursor)
+
+		if err != nil {
+			t.Fatalf("Should not have requested past the first 1000 results of a window, got %s", err)
+		}
+
+		for _, repository := range result.Repositories {
+			seen[repository.FullName] = true
+		}
+
+		if cursor = result.NextCursor; cursor == "" {
+			break
+		}
+	}
+
+	if cursor != "" {
+		t.Fatalf("Walk should have ended")
+	}
+
+	if len(seen) < builder.GITHUB_SEARCH_RESULTS_CAP {
+		t.Fatalf("Should have walked the first 1000 repositories of the dense window, walked %d", len(seen))
+	}
+}
+
+func TestGetGithubProjectsAfter_SmallResult(t *testing.T) {
+	mgr := &MockWindowedGithubRepository{createdAt: []time.Time{time.Now().Add(-time.Hour)}}
+	gs := NewGithubService(mgr)
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	result, err := gs.GetGithubProjectsAfter(context.Background(), grb, "")
+
+	if err != nil || len(result.Repositories) != 1 || result.NextCursor != "" {
+		t.Fatalf("Should have returned the single matching repository without next cursor")
+	}
+}
+
+func TestGetGithubProjectsAfter_InvalidCursor(t *testing.T) {
+	gs := NewGithubService(&MockWindowedGithubRepository{})
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+
+	if _, err := gs.GetGithubProjectsAfter(context.Background(), grb, "not a cursor"); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("Should have returned ErrInvalidCursor")
+	}
+}
@@ -1,17 +1,35 @@
 package services
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/LasramR/sclng-backend-test-lasramR/builder"
+	"github.com/LasramR/sclng-backend-test-lasramR/model"
 	"github.com/LasramR/sclng-backend-test-lasramR/repositories"
 )
 
+// Returned when the requested page starts past the results served by Github search
+var ErrPageOutOfRange = fmt.Errorf("page parameter exceeds the first %d search results served by Github, use cursor to walk past them", builder.GITHUB_SEARCH_RESULTS_CAP)
+
 // Github service business logic
 type GithubService interface {
 	// Returns repositories with computed stats from GithubAPIRepository
 	GetGithubProjectsWithStats(ctx context.Context, grb builder.GithubRequestBuilder) (repositories.GithubRepositoriesResult, error)
+	// Returns the page of repositories described by cursor, walking past the first 1000 results of Github search. An empty cursor starts the walk,
+	// the cursor of the next page is set in the result. error is ErrInvalidCursor if cursor is malformed
+	GetGithubProjectsAfter(ctx context.Context, grb builder.GithubRequestBuilder, cursor string) (repositories.GithubRepositoriesResult, error)
+	// Returns a single repository with its stats from GithubAPIRepository, a partial repository is returned along with the error of its failed fields
+	GetGithubProject(ctx context.Context, grb builder.GithubRequestBuilder, owner, name string) (*model.Repository, error)
+	// Returns language statistics aggregated accross the repositories matching the request
+	GetGithubLanguagesStats(ctx context.Context, grb builder.GithubRequestBuilder) (model.LanguagesStats, error)
+	// Returns the current rate limit state of the Github API
+	GetGithubRateLimit(ctx context.Context, grb builder.GithubRequestBuilder) (model.RateLimit, error)
 }
 
 type githubServiceImpl struct {
@@ -22,13 +40,272 @@ func (gs *githubServiceImpl) GetGithubProjectsWithStats(ctx context.Context, grb
 	timeoutCtx, cancelTimeout := context.WithTimeout(ctx, time.Second*30)
 	defer cancelTimeout()
 
-	result, err := gs.GithubRepository.GetManyRepositories(timeoutCtx, grb)
+	limit, page := grb.Paging()
+	if (page-1)*limit >= builder.GITHUB_SEARCH_RESULTS_CAP {
+		return repositories.GithubRepositoriesResult{}, ErrPageOutOfRange
+	}
 
-	if err != nil {
+	alternatives := grb.Alternatives()
+
+	if len(alternatives) == 1 {
+		return gs.GithubRepository.GetManyRepositories(timeoutCtx, alternatives[0])
+	}
+
+	// Alternative values cannot be expressed in a single Github query, each alternative is fetched concurrently then merged.
+	// Any of the first results of an alternative may be on the requested page once merged, they are all ranked from minimal
+	// requests fetched with the largest pages and only the repositories of the requested page are enriched
+	ranked := min(page*limit, builder.GITHUB_SEARCH_RESULTS_CAP)
+	rankedLimit := min(ranked, builder.GITHUB_SEARCH_MAX_LIMIT)
+	rankedPages := (ranked + rankedLimit - 1) / rankedLimit
+
+	pages := make([][]repositories.GithubRepositoriesResult, len(alternatives))
+	errs := make([]error, len(alternatives)*rankedPages)
+	var wg sync.WaitGroup
+
+	for i, alternative := range alternatives {
+		pages[i] = make([]repositories.GithubRepositoriesResult, rankedPages)
+
+		for p := 1; p <= rankedPages; p++ {
+			minimal := alternative.Minimal()
+			if err := errors.Join(minimal.Limit(rankedLimit), minimal.Page(p)); err != nil {
+				return repositories.GithubRepositoriesResult{}, err
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pages[i][p-1], errs[i*rankedPages+p-1] = gs.GithubRepository.GetManyRepositories(timeoutCtx, minimal)
+			}()
+		}
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
 		return repositories.GithubRepositoriesResult{}, err
 	}
 
-	return result, nil
+	results := make([]repositories.GithubRepositoriesResult, len(alternatives))
+	for i := range pages {
+		results[i] = concatRepositoriesPages(pages[i])
+	}
+
+	sort, order := grb.Sorting()
+	merged := mergeRepositoriesResults(results, sort, order, limit, page)
+
+	enriched, repositoryErrs := gs.GithubRepository.EnrichRepositories(timeoutCtx, grb, merged.Repositories)
+	merged.Repositories = enriched
+	merged.Errors = append(merged.Errors, repositoryErrs...)
+	merged.IncompleteResult = merged.IncompleteResult || len(repositoryErrs) != 0
+	slices.SortStableFunc(merged.Errors, func(a, b model.RepositoryError) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+
+	return merged, nil
+}
+
+// Concatenates the consecutive pages of a query into a single result, errors are reindexed accordingly
+func concatRepositoriesPages(pages []repositories.GithubRepositoriesResult) repositories.GithubRepositoriesResult {
+	concatenated := repositories.GithubRepositoriesResult{
+		Repositories: make([]*model.Repository, 0),
+	}
+
+	for _, page := range pages {
+		concatenated.Total = max(concatenated.Total, page.Total)
+		concatenated.IncompleteResult = concatenated.IncompleteResult || page.IncompleteResult
+
+		for _, repositoryErr := range page.Errors {
+			repositoryErr.Index += len(concatenated.Repositories)
+			concatenated.Errors = append(concatenated.Errors, repositoryErr)
+		}
+		concatenated.Repositories = append(concatenated.Repositories, page.Repositories...)
+	}
+
+	return concatenated
+}
+
+// Repository of an alternative result being merged, rank is its position in that result
+type mergedRepository struct {
+	repository *model.Repository
+	rank       int
+	errors     []model.RepositoryError
+}
+
+// Merges the results of alternative queries into the requested page of their union, repositories matching many alternatives are kept once.
+// Repositories are sorted by sort in order, ranks break ties and order repositories of sorts that can't be compared such as best-match.
+// Total is the sum of the alternatives totals, an upper bound as a repository may match many alternatives.
+// Errors are reindexed to the position of their repository in the merged result
+func mergeRepositoriesResults(results []repositories.GithubRepositoriesResult, sort, order string, limit, page int) repositories.GithubRepositoriesResult {
+	merged := repositories.GithubRepositoriesResult{
+		Repositories: make([]*model.Repository, 0),
+	}
+	candidates := make([]*mergedRepository, 0)
+	seen := make(map[string]bool)
+
+	for _, result := range results {
+		merged.Total += result.Total
+		merged.IncompleteResult = merged.IncompleteResult || result.IncompleteResult
+
+		// Candidate of each repository of the result, nil if it was already merged
+		positions := make([]*mergedRepository, len(result.Repositories))
+		for i, repository := range result.Repositories {
+			if repository != nil {
+				if seen[repository.FullName] {
+					continue
+				}
+				seen[repository.FullName] = true
+			}
+
+			positions[i] = &mergedRepository{repository: repository, rank: i}
+			candidates = append(candidates, positions[i])
+		}
+
+		for _, repositoryErr := range result.Errors {
+			if repositoryErr.Index < len(positions) && positions[repositoryErr.Index] != nil {
+				positions[repositoryErr.Index].errors = append(positions[repositoryErr.Index].errors, repositoryErr)
+			}
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b *mergedRepository) int {
+		return compareMergedRepositories(a, b, sort, order)
+	})
+
+	start := min((page-1)*limit, len(candidates))
+	for _, candidate := range candidates[start:min(start+limit, len(candidates))] {
+		for _, repositoryErr := range candidate.errors {
+			repositoryErr.Index = len(merged.Repositories)
+			merged.Errors = append(merged.Errors, repositoryErr)
+		}
+		merged.Repositories = append(merged.Repositories, candidate.repository)
+	}
+
+	return merged
+}
+
+// Compares merged repositories by sort in order then by rank, repositories that failed to be mapped come last
+func compareMergedRepositories(a, b *mergedRepository, sort, order string) int {
+	switch {
+	case a.repository == nil && b.repository == nil:
+		return cmp.Compare(a.rank, b.rank)
+	case a.repository == nil:
+		return 1
+	case b.repository == nil:
+		return -1
+	}
+
+	byField := 0
+	switch sort {
+	case "stars":
+		byField = cmp.Compare(a.repository.Stars, b.repository.Stars)
+	case "forks":
+		byField = cmp.Compare(a.repository.Forks, b.repository.Forks)
+	case "updated":
+		byField = a.repository.UpdatedAt.Compare(b.repository.UpdatedAt)
+	}
+
+	if order == "desc" {
+		byField = -byField
+	}
+
+	if byField != 0 {
+		return byField
+	}
+
+	return cmp.Compare(a.rank, b.rank)
+}
+
+func (gs *githubServiceImpl) GetGithubProjectsAfter(ctx context.Context, grb builder.GithubRequestBuilder, cursor string) (repositories.GithubRepositoriesResult, error) {
+	timeoutCtx, cancelTimeout := context.WithTimeout(ctx, time.Second*30)
+	defer cancelTimeout()
+
+	return gs.getGithubProjectsAfter(timeoutCtx, grb, cursor)
+}
+
+func (gs *githubServiceImpl) GetGithubProject(ctx context.Context, grb builder.GithubRequestBuilder, owner, name string) (*model.Repository, error) {
+	timeoutCtx, cancelTimeout := context.WithTimeout(ctx, time.Second*30)
+	defer cancelTimeout()
+
+	return gs.GithubRepository.GetRepository(timeoutCtx, grb, owner, name)
+}
+
+func (gs *githubServiceImpl) GetGithubLanguagesStats(ctx context.Context, grb builder.GithubRequestBuilder) (model.LanguagesStats, error) {
+	// Stats are computed over languages whatever the requested fields and enrichments
+	statsGrb := grb.Minimal()
+	if err := statsGrb.Fields("full_name,languages"); err != nil {
+		return model.LanguagesStats{}, err
+	}
+
+	result, err := gs.GetGithubProjectsWithStats(ctx, statsGrb)
+
+	if err != nil {
+		return model.LanguagesStats{}, err
+	}
+
+	return computeLanguagesStats(result), nil
+}
+
+func (gs *githubServiceImpl) GetGithubRateLimit(ctx context.Context, grb builder.GithubRequestBuilder) (model.RateLimit, error) {
+	timeoutCtx, cancelTimeout := context.WithTimeout(ctx, time.Second*30)
+	defer cancelTimeout()
+
+	return gs.GithubRepository.GetRateLimit(timeoutCtx, grb)
+}
+
+// Sums the languages of each repository of a result and computes per language stats
+func computeLanguagesStats(result repositories.GithubRepositoriesResult) model.LanguagesStats {
+	stats := model.LanguagesStats{
+		IncompleteResult: result.IncompleteResult,
+		Languages:        make(map[string]model.AggregatedLanguageStats),
+	}
+	bytesPerLanguage := make(map[string][]int)
+
+	for _, repository := range result.Repositories {
+		// Failed aggregations are represented as nil entries, failed languages as nil languages
+		if repository == nil || repository.Languages == nil {
+			continue
+		}
+
+		stats.RepositoryCount++
+		for _, language := range repository.Languages {
+			bytesPerLanguage[language.Name] = append(bytesPerLanguage[language.Name], language.Bytes)
+			stats.TotalBytes += language.Bytes
+		}
+	}
+
+	for language, bytes := range bytesPerLanguage {
+		slices.Sort(bytes)
+
+		total := 0
+		for _, b := range bytes {
+			total += b
+		}
+
+		var share float64
+		if stats.TotalBytes != 0 {
+			share = float64(total) / float64(stats.TotalBytes)
+		}
+
+		stats.Languages[language] = model.AggregatedLanguageStats{
+			TotalBytes:      total,
+			RepositoryCount: len(bytes),
+			Share:           share,
+			MeanBytes:       float64(total) / float64(len(bytes)),
+			MedianBytes:     median(bytes),
+		}
+	}
+
+	return stats
+}
+
+// Returns the median of a sorted non empty slice
+func median(sorted []int) float64 {
+	middle := len(sorted) / 2
+
+	if len(sorted)%2 == 0 {
+		return float64(sorted[middle-1]+sorted[middle]) / 2
+	}
+
+	return float64(sorted[middle])
 }
 
 func NewGithubService(gr repositories.GithubApiRepository) GithubService {
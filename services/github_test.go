@@ -2,7 +2,11 @@ package services
 
 import (
 	"context"
+	"errors"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/LasramR/sclng-backend-test-lasramR/builder"
 	"github.com/LasramR/sclng-backend-test-lasramR/model"
@@ -13,9 +17,18 @@ import (
 
 type MockGithubRepository struct {
 	err error
+	mu  sync.Mutex
+	// Requests received by GetManyRepositories
+	requests []builder.GithubRequestBuilder
+	// Number of repositories received by EnrichRepositories
+	enriched int
 }
 
-func (mgr *MockGithubRepository) GetManyRepositories(_ctx context.Context, _grb builder.GithubRequestBuilder) (repositories.GithubRepositoriesResult, error) {
+func (mgr *MockGithubRepository) GetManyRepositories(_ctx context.Context, grb builder.GithubRequestBuilder) (repositories.GithubRepositoriesResult, error) {
+	mgr.mu.Lock()
+	mgr.requests = append(mgr.requests, grb)
+	mgr.mu.Unlock()
+
 	if mgr.err != nil {
 		return repositories.GithubRepositoriesResult{}, mgr.err
 	}
@@ -29,27 +42,48 @@ func (mgr *MockGithubRepository) GetManyRepositories(_ctx context.Context, _grb
 				Owner:       "fmuiin14",
 				Description: "Brute force ethereum wallet mnemonics",
 				Repository:  "fmuiin14/BlazingTool",
-				License: util.NullableJsonField[string]{
-					Value:  "mit",
+				License: util.NullableJsonField[model.License]{
+					Value:  model.License{Key: "mit", SpdxId: "MIT", Name: "MIT License"},
 					IsNull: false,
 				},
 				RepositoryUrl: "https://github.com/fmuiin14/BlazingTool",
-				CreatedAt:     "2024-10-19T10:17:16Z",
-				UpdatedAt:     "2024-10-20T16:36:13Z",
-				Languages: model.Language{
-					"JavaScript": model.LanguageStats{
-						Bytes: 1548,
-					},
-					"SCSS": model.LanguageStats{
-						Bytes: 250,
-					},
-				},
-				Size: 156464,
+				CreatedAt:     time.Date(2024, time.October, 19, 10, 17, 16, 0, time.UTC),
+				UpdatedAt:     time.Date(2024, time.October, 20, 16, 36, 13, 0, time.UTC),
+				Languages:     model.NewLanguages(map[string]int{"JavaScript": 1548, "SCSS": 250}),
+				Size:          156464,
 			},
 		},
 	}, nil
 }
 
+func (mgr *MockGithubRepository) GetRepository(ctx context.Context, grb builder.GithubRequestBuilder, owner, name string) (*model.Repository, error) {
+	if mgr.err != nil {
+		return nil, mgr.err
+	}
+
+	result, _ := mgr.GetManyRepositories(ctx, grb)
+
+	return result.Repositories[0], nil
+}
+
+func (mgr *MockGithubRepository) GetRateLimit(ctx context.Context, grb builder.GithubRequestBuilder) (model.RateLimit, error) {
+	if mgr.err != nil {
+		return model.RateLimit{}, mgr.err
+	}
+
+	return model.RateLimit{
+		Core: model.RateLimitResource{Limit: 5000, Remaining: 4999, Used: 1},
+	}, nil
+}
+
+func (mgr *MockGithubRepository) EnrichRepositories(ctx context.Context, grb builder.GithubRequestBuilder, repositories []*model.Repository) ([]*model.Repository, []model.RepositoryError) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+	mgr.enriched += len(repositories)
+
+	return repositories, nil
+}
+
 func TestGetGithubProjectsWithStats(t *testing.T) {
 	gs := NewGithubService(&MockGithubRepository{})
 	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
@@ -63,3 +97,221 @@ func TestGetGithubProjectsWithStats(t *testing.T) {
 		t.Fatalf("Should have returned Github repository GetManyRepositories result")
 	}
 }
+
+func TestGetGithubProjectsWithStats_Alternatives(t *testing.T) {
+	mgr := &MockGithubRepository{}
+	gs := NewGithubService(mgr)
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	_ = grb.With("language", "go")
+	_ = grb.With("language", "rust")
+	_ = grb.Expand("contributors")
+
+	result, err := gs.GetGithubProjectsWithStats(context.Background(), grb)
+
+	if err != nil {
+		t.Fatalf("Should not have returned an error")
+	}
+
+	if len(result.Repositories) != 1 {
+		t.Fatalf("Should have merged the alternatives results, keeping repositories matching many alternatives once")
+	}
+
+	if result.Total != 2 {
+		t.Fatalf("Should have reported the sum of the alternatives totals as an upper bound, got %d", result.Total)
+	}
+
+	for _, request := range mgr.requests {
+		if !reflect.DeepEqual(request.Selection(), []string{"full_name"}) {
+			t.Fatalf("Alternatives should have been ranked without languages nor enrichments, selected %v", request.Selection())
+		}
+	}
+
+	if mgr.enriched != 1 {
+		t.Fatalf("Only the repositories of the page should have been enriched, enriched %d", mgr.enriched)
+	}
+}
+
+func TestGetGithubProjectsWithStats_AlternativesPages(t *testing.T) {
+	mgr := &MockGithubRepository{}
+	gs := NewGithubService(mgr)
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	for _, language := range []string{"a", "b", "c", "d", "e"} {
+		_ = grb.With("language", language)
+	}
+	_ = grb.Limit(10)
+	_ = grb.Page(7)
+
+	if _, err := gs.GetGithubProjectsWithStats(context.Background(), grb); err != nil {
+		t.Fatalf("Should not have returned an error, got %s", err)
+	}
+
+	if len(mgr.requests) != 5 {
+		t.Fatalf("Should have ranked the first pages of each alternative with a single request, made %d", len(mgr.requests))
+	}
+
+	if limit, page := mgr.requests[0].Paging(); limit != 70 || page != 1 {
+		t.Fatalf("Should have requested the first 70 results of each alternative, got limit %d page %d", limit, page)
+	}
+
+	_ = grb.Limit(100)
+	_ = grb.Page(11)
+
+	if _, err := gs.GetGithubProjectsWithStats(context.Background(), grb); !errors.Is(err, ErrPageOutOfRange) {
+		t.Fatalf("Should have rejected a page past the search results cap, got %v", err)
+	}
+
+	if len(mgr.requests) != 5 {
+		t.Fatalf("Should not have requested Github for a page past the search results cap")
+	}
+}
+
+func TestMergeRepositoriesResults_Sorted(t *testing.T) {
+	results := []repositories.GithubRepositoriesResult{
+		{
+			Total:        3,
+			Repositories: []*model.Repository{{FullName: "owner/a", Stars: 50}, {FullName: "owner/b", Stars: 20}, {FullName: "owner/c", Stars: 10}},
+		},
+		{
+			Total:        3,
+			Repositories: []*model.Repository{{FullName: "owner/d", Stars: 40}, {FullName: "owner/b", Stars: 20}, {FullName: "owner/e", Stars: 5}},
+			Errors:       []model.RepositoryError{{Index: 0, FullName: "owner/d", Enrichment: "languages", Reason: "timeout"}},
+		},
+	}
+
+	cases := []struct {
+		sort, order string
+		page        int
+		expected    []string
+		errors      []model.RepositoryError
+	}{
+		{"stars", "desc", 1, []string{"owner/a", "owner/d"}, []model.RepositoryError{{Index: 1, FullName: "owner/d", Enrichment: "languages", Reason: "timeout"}}},
+		{"stars", "desc", 2, []string{"owner/b", "owner/c"}, nil},
+		{"stars", "asc", 1, []string{"owner/e", "owner/c"}, nil},
+		{"best-match", "desc", 1, []string{"owner/a", "owner/d"}, []model.RepositoryError{{Index: 1, FullName: "owner/d", Enrichment: "languages", Reason: "timeout"}}},
+		{"best-match", "desc", 2, []string{"owner/b", "owner/c"}, nil},
+	}
+
+	for _, c := range cases {
+		merged := mergeRepositoriesResults(results, c.sort, c.order, 2, c.page)
+
+		fullNames := make([]string, 0, len(merged.Repositories))
+		for _, repository := range merged.Repositories {
+			fullNames = append(fullNames, repository.FullName)
+		}
+
+		if !reflect.DeepEqual(fullNames, c.expected) {
+			t.Fatalf("Should have returned page %d of the merged results sorted by %s %s %v, got %v", c.page, c.sort, c.order, c.expected, fullNames)
+		}
+
+		if !reflect.DeepEqual(merged.Errors, c.errors) {
+			t.Fatalf("Should have kept the errors of the repositories of the page, got %v", merged.Errors)
+		}
+
+		if merged.Total != 6 {
+			t.Fatalf("Should have summed the alternatives totals, got %d", merged.Total)
+		}
+	}
+}
+
+func TestMergeRepositoriesResults_Errors(t *testing.T) {
+	results := []repositories.GithubRepositoriesResult{
+		{
+			Repositories: []*model.Repository{{FullName: "owner/a"}, {FullName: "owner/b"}},
+			Errors:       []model.RepositoryError{{Index: 1, FullName: "owner/b", Enrichment: "languages", Reason: "timeout"}},
+		},
+		{
+			Repositories: []*model.Repository{{FullName: "owner/b"}, {FullName: "owner/c"}},
+			Errors: []model.RepositoryError{
+				{Index: 0, FullName: "owner/b", Enrichment: "languages", Reason: "timeout"},
+				{Index: 1, FullName: "owner/c", Enrichment: "releases", Reason: "timeout"},
+			},
+		},
+	}
+
+	merged := mergeRepositoriesResults(results, "best-match", "desc", 10, 1)
+
+	expected := []model.RepositoryError{
+		{Index: 1, FullName: "owner/b", Enrichment: "languages", Reason: "timeout"},
+		{Index: 2, FullName: "owner/c", Enrichment: "releases", Reason: "timeout"},
+	}
+
+	if !reflect.DeepEqual(merged.Errors, expected) {
+		t.Fatalf("Should have reindexed errors to the merged repositories, got %v", merged.Errors)
+	}
+}
+
+func TestGetGithubProject(t *testing.T) {
+	gs := NewGithubService(&MockGithubRepository{})
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	result, err := gs.GetGithubProject(context.Background(), grb, "fmuiin14", "BlazingTool")
+
+	if err != nil {
+		t.Fatalf("Should not have returned an error")
+	}
+
+	if result.FullName != "fmuiin14/BlazingTool" {
+		t.Fatalf("Should have returned Github repository GetRepository result")
+	}
+}
+
+func TestGetGithubLanguagesStats(t *testing.T) {
+	mgr := &MockGithubRepository{}
+	gs := NewGithubService(mgr)
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	_ = grb.Expand("contributors,releases")
+	result, err := gs.GetGithubLanguagesStats(context.Background(), grb)
+
+	if err != nil {
+		t.Fatalf("Should not have returned an error")
+	}
+
+	if result.RepositoryCount != 1 || result.TotalBytes != 1798 || result.Languages["SCSS"].TotalBytes != 250 {
+		t.Fatalf("Should have computed stats from Github repository GetManyRepositories result")
+	}
+
+	if len(mgr.requests) != 1 || !reflect.DeepEqual(mgr.requests[0].Selection(), []string{"full_name", "languages"}) {
+		t.Fatalf("Should have fetched the languages of the repositories only")
+	}
+}
+
+func TestComputeLanguagesStats(t *testing.T) {
+	result := repositories.GithubRepositoriesResult{
+		IncompleteResult: true,
+		Repositories: []*model.Repository{
+			{Languages: model.NewLanguages(map[string]int{"Go": 100, "Shell": 20})},
+			{Languages: model.NewLanguages(map[string]int{"Go": 300})},
+			nil,
+			{FullName: "owner/failed"},
+			{Languages: model.NewLanguages(map[string]int{"Go": 200, "Shell": 80})},
+		},
+	}
+
+	stats := computeLanguagesStats(result)
+
+	if stats.RepositoryCount != 3 || stats.TotalBytes != 700 || !stats.IncompleteResult {
+		t.Fatalf("Should have summed repositories whose languages did not fail")
+	}
+
+	expected := map[string]model.AggregatedLanguageStats{
+		"Go":    {TotalBytes: 600, RepositoryCount: 3, Share: 600.0 / 700.0, MeanBytes: 200, MedianBytes: 200},
+		"Shell": {TotalBytes: 100, RepositoryCount: 2, Share: 100.0 / 700.0, MeanBytes: 50, MedianBytes: 50},
+	}
+
+	if !reflect.DeepEqual(stats.Languages, expected) {
+		t.Fatalf("Should have computed per language stats, got %v", stats.Languages)
+	}
+}
+
+func TestGetGithubRateLimit(t *testing.T) {
+	gs := NewGithubService(&MockGithubRepository{})
+	grb, _ := builder.NewGithubRequestBuilder(version.GITHUB_API_2022_11_28)
+	result, err := gs.GetGithubRateLimit(context.Background(), grb)
+
+	if err != nil {
+		t.Fatalf("Should not have returned an error")
+	}
+
+	if result.Core.Remaining != 4999 {
+		t.Fatalf("Should have returned Github repository GetRateLimit result")
+	}
+}
@@ -0,0 +1,58 @@
+package util
+
+import (
+	"context"
+	"sync"
+)
+
+// In flight call of a Coalescer
+type coalescedCall[T any] struct {
+	done   chan struct{}
+	result Result[T]
+}
+
+// Deduplicates concurrent calls sharing the same key: only the first caller runs, every caller receives its result including its error
+type Coalescer[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall[T]
+}
+
+// Runs fn unless a call with the same key is already in flight, in which case its result is awaited.
+// shared is true when the result comes from another caller. Waiters stop waiting when their ctx is done
+func (c *Coalescer[T]) Do(ctx context.Context, key string, fn func() (T, error)) (value T, err error, shared bool) {
+	c.mu.Lock()
+	if call, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+
+		select {
+		case <-call.done:
+			return call.result.Value, call.result.Error, true
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err(), true
+		}
+	}
+
+	call := &coalescedCall[T]{
+		done: make(chan struct{}),
+	}
+	c.calls[key] = call
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(call.done)
+	}()
+
+	call.result.Value, call.result.Error = fn()
+
+	return call.result.Value, call.result.Error, false
+}
+
+func NewCoalescer[T any]() *Coalescer[T] {
+	return &Coalescer[T]{
+		calls: make(map[string]*coalescedCall[T]),
+	}
+}
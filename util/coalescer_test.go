@@ -0,0 +1,91 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoalescer_SharesResult(t *testing.T) {
+	coalescer := NewCoalescer[int]()
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func() (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, errors.New("shared error")
+	}
+
+	var wg sync.WaitGroup
+	results := make([]Result[int], 10)
+	for i := range results {
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			value, err, _ := coalescer.Do(ctx, "key", fn)
+			results[index] = Result[int]{Value: value, Error: err}
+		}(i)
+	}
+
+	// Lets every goroutine reach the coalescer before releasing the first call
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Fatalf("Concurrent calls with the same key should have run once, ran %d times", calls.Load())
+	}
+
+	for _, result := range results {
+		if result.Value != 42 || result.Error == nil || result.Error.Error() != "shared error" {
+			t.Fatalf("Every caller should have received the result and error of the call")
+		}
+	}
+}
+
+func TestCoalescer_DistinctKeysAndSequentialCalls(t *testing.T) {
+	coalescer := NewCoalescer[string]()
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	fn := func() (string, error) {
+		calls.Add(1)
+		return "value", nil
+	}
+
+	_, _, shared := coalescer.Do(ctx, "a", fn)
+	_, _, _ = coalescer.Do(ctx, "b", fn)
+	_, _, _ = coalescer.Do(ctx, "a", fn)
+
+	if shared || calls.Load() != 3 {
+		t.Fatalf("Calls that are not concurrent should not be coalesced")
+	}
+}
+
+func TestCoalescer_WaiterContextDone(t *testing.T) {
+	coalescer := NewCoalescer[int]()
+	release := make(chan struct{})
+	defer close(release)
+
+	go func() {
+		_, _, _ = coalescer.Do(context.Background(), "key", func() (int, error) {
+			<-release
+			return 1, nil
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err, shared := coalescer.Do(ctx, "key", func() (int, error) { return 2, nil })
+
+	if !shared || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Waiter should stop waiting when its context is done")
+	}
+}
@@ -1,7 +1,9 @@
 package util
 
 import (
+	"cmp"
 	"context"
+	"slices"
 	"sync"
 	"time"
 )
@@ -16,11 +18,12 @@ type Mappable[T any] interface {
 type MapperFunc[S, D any] func(ctx context.Context, s S) (D, error)
 
 // Given a Mappable source of type S, asynchronously transform the element to an array of type D
-// Also returns a list of errors that occured during mapping process, check for error with len(errs) != 0
-func AsyncListMapper[S any, A Mappable[S], D any](ctx context.Context, source A, mapFunc MapperFunc[S, D], timeout time.Duration) ([]D, []*error) {
+// Also returns the errors that occured during mapping process ordered by index, check for error with len(errs) != 0.
+// Values returned along with an error are kept as partial results
+func AsyncListMapper[S any, A Mappable[S], D any](ctx context.Context, source A, mapFunc MapperFunc[S, D], timeout time.Duration) ([]D, []IndexedError) {
 	sourceCount := len(source.Items())
 	mapped := make([]D, sourceCount)
-	errorsCollected := make([]*error, 0)
+	errorsCollected := make([]IndexedError, 0)
 
 	var wg sync.WaitGroup
 	respCh := make(chan *IndexedResult[D], sourceCount)
@@ -33,17 +36,15 @@ func AsyncListMapper[S any, A Mappable[S], D any](ctx context.Context, source A,
 			timeoutCtx, cancelTimeout := context.WithTimeout(ctx, timeout)
 			defer cancelTimeout()
 
-			if d, err := mapFunc(timeoutCtx, s); err != nil {
-				respCh <- &IndexedResult[D]{
-					Error: &err,
-					Index: index,
-				}
-			} else {
-				respCh <- &IndexedResult[D]{
-					Value: d,
-					Index: i,
-				}
+			d, err := mapFunc(timeoutCtx, s)
+			result := &IndexedResult[D]{
+				Value: d,
+				Index: index,
 			}
+			if err != nil {
+				result.Error = &err
+			}
+			respCh <- result
 		}(i, v)
 	}
 
@@ -53,12 +54,15 @@ func AsyncListMapper[S any, A Mappable[S], D any](ctx context.Context, source A,
 	}()
 
 	for result := range respCh {
+		mapped[result.Index] = result.Value
 		if result.Error != nil {
-			errorsCollected = append(errorsCollected, result.Error)
-		} else {
-			mapped[result.Index] = result.Value
+			errorsCollected = append(errorsCollected, IndexedError{Index: result.Index, Error: *result.Error})
 		}
 	}
 
+	slices.SortFunc(errorsCollected, func(a, b IndexedError) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+
 	return mapped, errorsCollected
 }
@@ -2,6 +2,7 @@ package util
 
 import (
 	"context"
+	"errors"
 	"reflect"
 	"strings"
 	"testing"
@@ -40,3 +41,24 @@ func TestAsyncListMapper(t *testing.T) {
 		t.Fatalf("Async list mapping should have returned expected result")
 	}
 }
+
+func TestAsyncListMapper_PartialResults(t *testing.T) {
+	source := Bookself[string]{
+		books: []string{"lotr", "", "d&d manual", ""},
+	}
+
+	actual, errorsCollected := AsyncListMapper(context.Background(), source, func(ctx context.Context, el string) (string, error) {
+		if el == "" {
+			return "UNTITLED", errors.New("book without title")
+		}
+		return strings.ToUpper(el), nil
+	}, time.Hour)
+
+	if !reflect.DeepEqual(actual, []string{"LOTR", "UNTITLED", "D&D MANUAL", "UNTITLED"}) {
+		t.Fatalf("Async list mapping should have kept the values returned along with an error, got %v", actual)
+	}
+
+	if len(errorsCollected) != 2 || errorsCollected[0].Index != 1 || errorsCollected[1].Index != 3 {
+		t.Fatalf("Async list mapping should have collected the errors ordered by index, got %v", errorsCollected)
+	}
+}
@@ -6,6 +6,12 @@ type Result[T any] struct {
 	Error error
 }
 
+// Error that occured while processing the item at Index of a list
+type IndexedError struct {
+	Index int
+	Error error
+}
+
 // Utility type for multi value buffered channels
 type IndexedResult[T any] struct {
 	Value T
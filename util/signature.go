@@ -0,0 +1,36 @@
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// Returned when a signed value was not signed with the expected secret or was tampered with
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Returns value suffixed with its HMAC-SHA256 signature computed with secret, value must not contain a dot
+func Sign(secret []byte, value string) string {
+	return value + "." + signature(secret, value)
+}
+
+// Verifies a value signed with Sign and returns it without its signature
+func Verify(secret []byte, signed string) (string, error) {
+	value, sig, found := strings.Cut(signed, ".")
+
+	if !found || !hmac.Equal([]byte(sig), []byte(signature(secret, value))) {
+		return "", ErrInvalidSignature
+	}
+
+	return value, nil
+}
+
+// Computes the url safe signature of value
+func signature(secret []byte, value string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(value))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
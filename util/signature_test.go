@@ -0,0 +1,27 @@
+package util
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSign_Verify(t *testing.T) {
+	secret := []byte("secret")
+	signed := Sign(secret, "cursor")
+
+	if value, err := Verify(secret, signed); err != nil || value != "cursor" {
+		t.Fatalf("Verify should return the signed value")
+	}
+
+	if _, err := Verify([]byte("other secret"), signed); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Verify should reject a value signed with another secret")
+	}
+
+	if _, err := Verify(secret, "tampered"+signed); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Verify should reject a tampered value")
+	}
+
+	if _, err := Verify(secret, "cursor"); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("Verify should reject an unsigned value")
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
-	"strconv"
 )
 
 // Return the complete URL from a query including protocol and host with the given url parameters
@@ -23,29 +22,15 @@ func FullUrlFromRequest(r *http.Request) string {
 	return fullUrlFrom(r, r.URL.Query())
 }
 
-// Given a request, returns its url with query param page incremented or set to 2
-func NextFullUrlFromRequest(r *http.Request) string {
+// Given a request, returns its url with query param key set to value
+func FullUrlFromRequestWith(r *http.Request, key, value string) string {
 	queryParams := r.URL.Query()
-
-	if page, err := strconv.Atoi(queryParams.Get("page")); err == nil {
-		queryParams.Set("page", fmt.Sprintf("%d", page+1))
-	} else {
-		queryParams.Set("page", "2")
-	}
+	queryParams.Set(key, value)
 
 	return fullUrlFrom(r, queryParams)
 }
 
-// Given a request, returns its url with query param page decremented.
-// Will return an empty string if page=1
-func PreviousFullUrlFromRequest(r *http.Request) string {
-	queryParams := r.URL.Query()
-
-	if page, err := strconv.Atoi(queryParams.Get("page")); err != nil {
-		return ""
-	} else {
-		queryParams.Set("page", fmt.Sprintf("%d", page-1))
-	}
-
+// Given a request, returns its url with its query params replaced by queryParams
+func FullUrlFromQuery(r *http.Request, queryParams url.Values) string {
 	return fullUrlFrom(r, queryParams)
 }
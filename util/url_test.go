@@ -27,46 +27,13 @@ func TestFullUrlFromRequest(t *testing.T) {
 	}
 }
 
-func TestNextFullUrlFromRequest_WithoutPageQueryArg(t *testing.T) {
-	req, _ := http.NewRequest(http.MethodGet, "http://big-data-warahouse.xyz?hello=world", nil)
-
-	expected := "http://big-data-warahouse.xyz?hello=world&page=2#"
-	actual := NextFullUrlFromRequest(req)
-
-	if expected != actual {
-		t.Fatalf("NextFullUrlFromRequest should returns original URL suffixed with # and original query params and page set to 2")
-	}
-}
-
-func TestNextFullUrlFromRequest_WithPageQueryArg(t *testing.T) {
-	req, _ := http.NewRequest(http.MethodGet, "http://big-data-warahouse.xyz?hello=world&page=12", nil)
-
-	expected := "http://big-data-warahouse.xyz?hello=world&page=13#"
-	actual := NextFullUrlFromRequest(req)
-
-	if expected != actual {
-		t.Fatalf("NextFullUrlFromRequest should returns original URL suffixed with # and original query params and page set to page+1")
-	}
-}
-
-func TestNextPreviousFullUrlFromRequest_WithoutPageQueryArg(t *testing.T) {
-	req, _ := http.NewRequest(http.MethodGet, "http://big-data-warahouse.xyz?hello=world", nil)
-
-	expected := ""
-	actual := PreviousFullUrlFromRequest(req)
-
-	if expected != actual {
-		t.Fatalf("PreviousFullUrlFromRequest should returns nothing page query argument is missing")
-	}
-}
-
-func TestNextPreviousFullUrlFromRequest_WithPageQueryArg(t *testing.T) {
+func TestFullUrlFromQuery(t *testing.T) {
 	req, _ := http.NewRequest(http.MethodGet, "http://big-data-warahouse.xyz?hello=world&page=12", nil)
 
-	expected := "http://big-data-warahouse.xyz?hello=world&page=11#"
-	actual := PreviousFullUrlFromRequest(req)
+	expected := "http://big-data-warahouse.xyz?cursor=abc#"
+	actual := FullUrlFromQuery(req, url.Values{"cursor": {"abc"}})
 
 	if expected != actual {
-		t.Fatalf("PreviousFullUrlFromRequest should returns original URL suffixed with # and original query params and page set to page-1")
+		t.Fatalf("FullUrlFromQuery should returns original URL suffixed with # and the given query params")
 	}
 }